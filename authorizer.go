@@ -0,0 +1,261 @@
+package roller
+
+import (
+	"fmt"
+	"sort"
+)
+
+//ViolationKind is the kind of rule a Violation broke
+type ViolationKind int
+
+const (
+	//GrantViolation is a grant of a node the actor doesn't hold
+	GrantViolation ViolationKind = iota
+	//LevelViolation is a level change the actor isn't higher than
+	LevelViolation
+	//GroupViolation is an assignment of a group the actor isn't higher level than
+	GroupViolation
+	//IncludeViolation is an include of a Bundle that grants a node the actor doesn't hold, or can't be expanded
+	IncludeViolation
+)
+
+func (k ViolationKind) String() string {
+	switch k {
+	case GrantViolation:
+		return "grant"
+	case LevelViolation:
+		return "level"
+	case GroupViolation:
+		return "group"
+	case IncludeViolation:
+		return "include"
+	}
+	return fmt.Sprintf("ViolationKind(%d)", int(k))
+}
+
+//Violation is a single change an Authorizer rejected
+type Violation struct {
+	Kind ViolationKind
+	//Entry is where the violating change is, one of "permission", "overwrites" or "flags.<name>"
+	//it's "groups" for GroupViolation, and for LevelViolation of the level newly assigned groups add up to
+	Entry string
+	//Node is the granted node for GrantViolation, the scoped level key for LevelViolation or "" for Entry.Level,
+	//the group ID for GroupViolation and the bundle name for IncludeViolation
+	Node string
+	//Level is the rejected level for LevelViolation, or the processed level of the group for GroupViolation
+	Level int
+}
+
+func (v Violation) String() string {
+	switch v.Kind {
+	case GrantViolation:
+		return fmt.Sprintf("%v: grant of \"%v\" is not held by actor", v.Entry, v.Node)
+	case LevelViolation:
+		if v.Node == "" {
+			return fmt.Sprintf("%v: level %v is not below actor", v.Entry, v.Level)
+		}
+		return fmt.Sprintf("%v: level %v of \"%v\" is not below actor", v.Entry, v.Level, v.Node)
+	case GroupViolation:
+		return fmt.Sprintf("%v: group \"%v\" with level %v is not below actor", v.Entry, v.Node, v.Level)
+	case IncludeViolation:
+		return fmt.Sprintf("%v: include of \"%v\" grants nodes not held by actor", v.Entry, v.Node)
+	}
+	return fmt.Sprintf("%v: %v violation", v.Entry, v.Kind)
+}
+
+//Authorizer checks if an actor is allowed to make a change to a RawList or a Group
+//an actor may only grant nodes it holds itself, set levels it's higher than, and assign groups it's higher level than
+//only the changes between before and after are checked, so unrelated edits are not blocked by what's already there
+//
+//for RawList, changed levels are checked by the level after is processed into, as an Entry.Level may add onto the groups
+//for Group, an Entry.Level without Entry.SetLevel is an increase, only raising it is checked
+type Authorizer struct {
+	//Actor is the compiled List of who's making the change
+	Actor List
+	//Comparator is used to check if Actor holds a node, and is higher level
+	//if it's a PatternComparator, granted patterns are checked with PatternComparator.Covers
+	Comparator Comparator
+	//Processor is used to process newly assigned groups and changed levels, only required for RawList
+	//it's also used to expand newly included bundles if it implements EntryExpander,
+	//otherwise every newly included bundle is a violation
+	Processor Processor
+	//Deliminator is used to find the actor's scoped level that applies to a scoped level key
+	//defaults to "", which only uses a scoped level with the exact same key
+	Deliminator string
+}
+
+//RawList checks the changes from before to after, returns all violations found
+//returns error if after or a newly assigned group can't be processed
+func (a Authorizer) RawList(before RawList, after RawList) ([]Violation, error) {
+	var vs []Violation
+	var processed *List
+	if levelChanged(before.Overwrites, after.Overwrites) {
+		l, err := a.Processor.Process(after)
+		if err != nil {
+			return nil, err
+		}
+		processed = &l
+	}
+	vs = a.entry(vs, "overwrites", before.Overwrites, after.Overwrites, processed)
+	vs, err := a.flags(vs, before.Flags, after.Flags, func(name string) (List, error) {
+		return a.Processor.ProcessFlags(after, name)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	had := newNodeSet(before.Groups)
+	added := false
+	for _, g := range after.Groups {
+		if _, ok := had[g]; ok {
+			continue
+		}
+		had[g] = struct{}{}
+		added = true
+		l, err := a.Processor.Process(RawList{Groups: []string{g}, Tenant: after.Tenant})
+		if err != nil {
+			return nil, err
+		}
+		if !a.Comparator.IsHigherLevel(a.Actor, l) {
+			vs = append(vs, Violation{Kind: GroupViolation, Entry: "groups", Node: g, Level: l.Level})
+		}
+	}
+	if added {
+		l, err := a.Processor.Process(after)
+		if err != nil {
+			return nil, err
+		}
+		if !a.Comparator.IsHigherLevel(a.Actor, List{Level: l.Level}) {
+			vs = append(vs, Violation{Kind: LevelViolation, Entry: "groups", Level: l.Level})
+		}
+	}
+	return vs, nil
+}
+
+//Group checks the changes from before to after, returns all violations found
+//use a zero Group as before for a newly created group
+func (a Authorizer) Group(before Group, after Group) []Violation {
+	var vs []Violation
+	vs = a.entry(vs, "permission", before.Permission, after.Permission, nil)
+	vs, _ = a.flags(vs, before.Flags, after.Flags, nil)
+	return vs
+}
+
+//flags checks every flag entry in after against the same named one in before
+//process is used to process a flag with changed levels, nil checks the raw levels
+func (a Authorizer) flags(vs []Violation, before map[string]FlagEntry, after map[string]FlagEntry, process func(name string) (List, error)) ([]Violation, error) {
+	names := make([]string, 0, len(after))
+	for n := range after {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	for _, n := range names {
+		var processed *List
+		if process != nil && levelChanged(before[n].Entry, after[n].Entry) {
+			l, err := process(n)
+			if err != nil {
+				return nil, err
+			}
+			processed = &l
+		}
+		vs = a.entry(vs, "flags."+n, before[n].Entry, after[n].Entry, processed)
+	}
+	return vs, nil
+}
+
+//entry checks the grants and levels that changed from before to after
+//changed levels are checked by the level of processed, or as raw levels if it's nil
+func (a Authorizer) entry(vs []Violation, where string, before Entry, after Entry, processed *List) []Violation {
+	if after.Level != before.Level || after.SetLevel != before.SetLevel {
+		level, check := after.Level, after.SetLevel || after.Level > 0 && (before.SetLevel || after.Level > before.Level)
+		if processed != nil {
+			level, check = processed.Level, true
+		}
+		if check && !a.Comparator.IsHigherLevel(a.Actor, List{Level: level}) {
+			vs = append(vs, Violation{Kind: LevelViolation, Entry: where, Level: level})
+		}
+	}
+
+	keys := make([]string, 0, len(after.Levels))
+	for k := range after.Levels {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		v := after.Levels[k]
+		old, ok := before.Levels[k]
+		if ok && old == v && after.SetLevel == before.SetLevel {
+			continue
+		}
+		check := after.SetLevel || v > 0 && (!ok || before.SetLevel || v > old)
+		if processed != nil {
+			v, check = levelFor(*processed, k, a.Deliminator), true
+		}
+		actor := List{Level: levelFor(a.Actor, k, a.Deliminator)}
+		if check && !a.Comparator.IsHigherLevel(actor, List{Level: v}) {
+			vs = append(vs, Violation{Kind: LevelViolation, Entry: where, Node: k, Level: v})
+		}
+	}
+
+	had := newNodeSet(before.Include)
+	for _, n := range after.Include {
+		if _, ok := had[n]; ok {
+			continue
+		}
+		had[n] = struct{}{}
+		if !a.bundleHeld(n) {
+			vs = append(vs, Violation{Kind: IncludeViolation, Entry: where, Node: n})
+		}
+	}
+
+	had = newNodeSet(before.Grant)
+	for _, n := range after.Grant {
+		if _, ok := had[n]; ok {
+			continue
+		}
+		had[n] = struct{}{}
+		if !a.holds(n) {
+			vs = append(vs, Violation{Kind: GrantViolation, Entry: where, Node: n})
+		}
+	}
+	return vs
+}
+
+//holds returns true if the actor holds the granted node, using PatternComparator.Covers if possible
+func (a Authorizer) holds(node string) bool {
+	if pc, ok := a.Comparator.(PatternComparator); ok {
+		return pc.Covers(a.Actor, node)
+	}
+	return a.Comparator.HasPermission(a.Actor, node)
+}
+
+//levelChanged returns true if the level, or any scoped level of after differs from before
+func levelChanged(before Entry, after Entry) bool {
+	if after.Level != before.Level || after.SetLevel != before.SetLevel {
+		return true
+	}
+	for k, v := range after.Levels {
+		if old, ok := before.Levels[k]; !ok || old != v {
+			return true
+		}
+	}
+	return false
+}
+
+//bundleHeld returns true if the actor holds every node the bundle grants
+func (a Authorizer) bundleHeld(name string) bool {
+	x, ok := a.Processor.(EntryExpander)
+	if !ok {
+		return false
+	}
+	e, err := x.ExpandEntry(Entry{Include: []string{name}})
+	if err != nil {
+		return false
+	}
+	for _, n := range e.Grant {
+		if !a.holds(n) {
+			return false
+		}
+	}
+	return true
+}
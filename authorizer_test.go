@@ -0,0 +1,185 @@
+package roller
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestAuthorizer_RawList(t *testing.T) {
+	a := Authorizer{
+		Actor: List{
+			Level:      10,
+			Levels:     map[string]int{"chat": 20},
+			Permission: []string{"chat*", "world.build"},
+		},
+		Comparator:  ImplicitComparator{Deliminator: ".", Terminator: "*"},
+		Deliminator: ".",
+		Processor: BasicProcessor{Provider: &dummyProvider{groups: []Group{
+			{ID: "member", Permission: Entry{Level: 1}},
+			{ID: "admin", Permission: Entry{Level: 50}},
+			{ID: "peer", Permission: Entry{Level: 10}},
+		}}},
+	}
+	tests := []struct {
+		name    string
+		before  RawList
+		after   RawList
+		want    []Violation
+		wantErr bool
+	}{
+		{
+			name:   "Allowed",
+			before: RawList{},
+			after: RawList{
+				Groups:     []string{"member"},
+				Overwrites: Entry{Level: 5, Levels: map[string]int{"chat.mod": 15}, Grant: []string{"chat.send", "world.build"}},
+			},
+		}, {
+			name:   "Grant not held",
+			before: RawList{},
+			after:  RawList{Overwrites: Entry{Grant: []string{"chat.send", "admin*", "world*"}}},
+			want: []Violation{
+				{Kind: GrantViolation, Entry: "overwrites", Node: "admin*"},
+				{Kind: GrantViolation, Entry: "overwrites", Node: "world*"},
+			},
+		}, {
+			name:   "Existing grants ignored",
+			before: RawList{Overwrites: Entry{Grant: []string{"admin*"}}, Groups: []string{"admin"}},
+			after:  RawList{Overwrites: Entry{Grant: []string{"admin*", "chat.send"}}, Groups: []string{"admin", "member"}},
+			want:   []Violation{{Kind: LevelViolation, Entry: "groups", Level: 51}},
+		}, {
+			name:   "Levels",
+			before: RawList{Overwrites: Entry{Level: 3}},
+			after: RawList{
+				Overwrites: Entry{Level: 10, Levels: map[string]int{"chat": 19, "world": 11}},
+				Flags: map[string]FlagEntry{
+					"night": {Entry: Entry{SetLevel: true, Level: 12}},
+				},
+			},
+			want: []Violation{
+				{Kind: LevelViolation, Entry: "overwrites", Level: 10},
+				{Kind: LevelViolation, Entry: "overwrites", Node: "world", Level: 11},
+				{Kind: LevelViolation, Entry: "flags.night", Level: 12},
+			},
+		}, {
+			name:   "Groups",
+			before: RawList{Groups: []string{"member"}},
+			after:  RawList{Groups: []string{"member", "peer", "admin", "admin"}},
+			want: []Violation{
+				{Kind: GroupViolation, Entry: "groups", Node: "peer", Level: 10},
+				{Kind: GroupViolation, Entry: "groups", Node: "admin", Level: 50},
+				{Kind: LevelViolation, Entry: "groups", Level: 111},
+			},
+		}, {
+			name:   "Overwrites add onto groups",
+			before: RawList{Groups: []string{"member"}},
+			after:  RawList{Groups: []string{"member"}, Overwrites: Entry{Level: 9, Levels: map[string]int{"chat": 5}}},
+			want: []Violation{
+				{Kind: LevelViolation, Entry: "overwrites", Level: 10},
+			},
+		}, {
+			name:   "Groups add up",
+			before: RawList{Overwrites: Entry{Level: 5}},
+			after:  RawList{Overwrites: Entry{Level: 5}, Groups: []string{"member", "member", "member", "member", "member"}},
+			want: []Violation{
+				{Kind: LevelViolation, Entry: "groups", Level: 10},
+			},
+		}, {
+			name:    "Missing group",
+			after:   RawList{Groups: []string{"ghost"}},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			as := assert.New(t)
+			got, err := a.RawList(tt.before, tt.after)
+			if tt.wantErr {
+				var e MissingGroupError
+				as.ErrorAs(err, &e)
+				return
+			}
+			as.NoError(err)
+			as.Equal(tt.want, got)
+		})
+	}
+}
+
+func TestAuthorizer_Group(t *testing.T) {
+	as := assert.New(t)
+	a := Authorizer{
+		Actor:      List{Level: 10, Permission: []string{"chat.send", "chat.kick"}},
+		Comparator: ExplicitComparator{},
+	}
+	before := Group{ID: "g", Permission: Entry{Grant: []string{"admin"}}}
+	after := Group{ID: "g", Permission: Entry{Grant: []string{"admin", "chat.send", "server.stop"}}, Flags: map[string]FlagEntry{
+		"b": {Entry: Entry{Level: 9, Grant: []string{"chat.kick"}}},
+		"a": {Entry: Entry{Level: 100, Grant: []string{"chat.ban"}}},
+	}}
+	got := a.Group(before, after)
+	as.Equal([]Violation{
+		{Kind: GrantViolation, Entry: "permission", Node: "server.stop"},
+		{Kind: LevelViolation, Entry: "flags.a", Level: 100},
+		{Kind: GrantViolation, Entry: "flags.a", Node: "chat.ban"},
+	}, got)
+	as.Empty(a.Group(after, after))
+}
+
+func TestAuthorizer_GroupLevel(t *testing.T) {
+	as := assert.New(t)
+	a := Authorizer{
+		Actor:      List{Level: 10, Levels: map[string]int{"chat": 10}},
+		Comparator: ExplicitComparator{},
+	}
+	as.Empty(a.Group(Group{Permission: Entry{Level: 50}}, Group{Permission: Entry{Level: 9}}), "lowering an increase is allowed")
+	as.Empty(a.Group(Group{}, Group{Permission: Entry{Level: -20, Levels: map[string]int{"chat": -5}}}))
+	as.Equal([]Violation{
+		{Kind: LevelViolation, Entry: "permission", Level: 10},
+		{Kind: LevelViolation, Entry: "permission", Node: "chat", Level: 10},
+	}, a.Group(Group{Permission: Entry{Level: 5, Levels: map[string]int{"chat": 5}}}, Group{Permission: Entry{Level: 10, Levels: map[string]int{"chat": 10}}}))
+	as.Equal([]Violation{
+		{Kind: LevelViolation, Entry: "permission", Level: 12},
+	}, a.Group(Group{Permission: Entry{Level: 50}}, Group{Permission: Entry{SetLevel: true, Level: 12}}), "a set level is compared as is, even if it's lower")
+}
+
+func TestAuthorizer_Pattern(t *testing.T) {
+	as := assert.New(t)
+	a := Authorizer{
+		Actor:      List{Level: 10, Permission: []string{"admin.*", "chat.**"}},
+		Comparator: GlobComparator{Deliminator: "."},
+	}
+	after := Group{Permission: Entry{Grant: []string{"admin.kick", "admin.*", "admin.k*", "admin.**", "chat.*.send", "chat.**", "*"}}}
+	as.Equal([]Violation{
+		{Kind: GrantViolation, Entry: "permission", Node: "admin.**"},
+		{Kind: GrantViolation, Entry: "permission", Node: "*"},
+	}, a.Group(Group{}, after))
+}
+
+func TestAuthorizer_Include(t *testing.T) {
+	as := assert.New(t)
+	a := Authorizer{
+		Actor:      List{Level: 10, Permission: []string{"chat.send", "chat.read", "chat.emote"}},
+		Comparator: ExplicitComparator{},
+		Processor:  BasicProcessor{Bundles: testBundles()},
+	}
+	before := Group{Permission: Entry{Include: []string{"social"}}}
+	after := Group{Permission: Entry{Include: []string{"social", "chat", "emote", "muted", "diamond", "unknown", "loop1"}}}
+	as.Equal([]Violation{
+		{Kind: IncludeViolation, Entry: "permission", Node: "diamond"},
+		{Kind: IncludeViolation, Entry: "permission", Node: "unknown"},
+		{Kind: IncludeViolation, Entry: "permission", Node: "loop1"},
+	}, a.Group(before, after))
+
+	a.Processor = nil
+	as.Equal([]Violation{{Kind: IncludeViolation, Entry: "permission", Node: "chat"}}, a.Group(Group{}, Group{Permission: Entry{Include: []string{"chat"}}}))
+}
+
+func TestViolation_String(t *testing.T) {
+	as := assert.New(t)
+	as.Equal(`overwrites: grant of "admin*" is not held by actor`, Violation{Kind: GrantViolation, Entry: "overwrites", Node: "admin*"}.String())
+	as.Equal(`permission: level 5 is not below actor`, Violation{Kind: LevelViolation, Entry: "permission", Level: 5}.String())
+	as.Equal(`permission: level 5 of "chat" is not below actor`, Violation{Kind: LevelViolation, Entry: "permission", Node: "chat", Level: 5}.String())
+	as.Equal(`groups: group "admin" with level 50 is not below actor`, Violation{Kind: GroupViolation, Entry: "groups", Node: "admin", Level: 50}.String())
+	as.Equal(`overwrites: include of "social" grants nodes not held by actor`, Violation{Kind: IncludeViolation, Entry: "overwrites", Node: "social"}.String())
+	as.Equal("ViolationKind(9)", ViolationKind(9).String())
+}
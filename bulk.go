@@ -0,0 +1,172 @@
+package roller
+
+import "strings"
+
+//BulkComparator is a Comparator that is able to check multiple nodes against the same List at once
+//implementations should build their lookup structure once per call instead of once per node
+type BulkComparator interface {
+	Comparator
+	//HasAll returns true if the List has every given node
+	//returns true if no nodes are given
+	HasAll(p List, nodes ...string) bool
+	//HasAny returns true if the List has at least one of the given nodes
+	//returns false if no nodes are given
+	HasAny(p List, nodes ...string) bool
+	//Filter returns the subset of nodes that the List has, preserving the order of nodes
+	Filter(p List, nodes []string) []string
+}
+
+//Insures that ExplicitComparator is BulkComparator
+var _ BulkComparator = (*ExplicitComparator)(nil)
+
+//HasAll checks if List has every exact node
+func (j ExplicitComparator) HasAll(p List, nodes ...string) bool {
+	set := newNodeSet(j.Normalizer.normalizeAll(p.Permission))
+	for _, n := range nodes {
+		if _, ok := set[j.Normalizer.normalize(n)]; !ok {
+			return false
+		}
+	}
+	return true
+}
+
+//HasAny checks if List has at least one exact node
+func (j ExplicitComparator) HasAny(p List, nodes ...string) bool {
+	set := newNodeSet(j.Normalizer.normalizeAll(p.Permission))
+	for _, n := range nodes {
+		if _, ok := set[j.Normalizer.normalize(n)]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+//Filter returns the nodes that List has exactly
+func (j ExplicitComparator) Filter(p List, nodes []string) []string {
+	set := newNodeSet(j.Normalizer.normalizeAll(p.Permission))
+	o := make([]string, 0, len(nodes))
+	for _, n := range nodes {
+		if _, ok := set[j.Normalizer.normalize(n)]; ok {
+			o = append(o, n)
+		}
+	}
+	return o
+}
+
+//Insures that ImplicitComparator is BulkComparator
+var _ BulkComparator = (*ImplicitComparator)(nil)
+
+//HasAll checks if List has every node, following the same rules as ImplicitComparator.HasPermission
+func (j ImplicitComparator) HasAll(p List, nodes ...string) bool {
+	set := newNodeSet(j.Normalizer.normalizeAll(p.Permission))
+	exclude := newNodeSet(j.Normalizer.normalizeAll(p.Exclude))
+	var buf []byte
+	for _, n := range nodes {
+		var ok bool
+		if ok, buf = j.matchVariant(set, exclude, n, buf); !ok {
+			return false
+		}
+	}
+	return true
+}
+
+//HasAny checks if List has at least one node, following the same rules as ImplicitComparator.HasPermission
+func (j ImplicitComparator) HasAny(p List, nodes ...string) bool {
+	set := newNodeSet(j.Normalizer.normalizeAll(p.Permission))
+	exclude := newNodeSet(j.Normalizer.normalizeAll(p.Exclude))
+	var buf []byte
+	for _, n := range nodes {
+		var ok bool
+		if ok, buf = j.matchVariant(set, exclude, n, buf); ok {
+			return true
+		}
+	}
+	return false
+}
+
+//Filter returns the nodes that List has, following the same rules as ImplicitComparator.HasPermission
+func (j ImplicitComparator) Filter(p List, nodes []string) []string {
+	set := newNodeSet(j.Normalizer.normalizeAll(p.Permission))
+	exclude := newNodeSet(j.Normalizer.normalizeAll(p.Exclude))
+	var buf []byte
+	o := make([]string, 0, len(nodes))
+	for _, n := range nodes {
+		var ok bool
+		if ok, buf = j.matchVariant(set, exclude, n, buf); ok {
+			o = append(o, n)
+		}
+	}
+	return o
+}
+
+//matchVariant checks if node is granted by set while respecting exclude
+//without any exclusion it's the same as hasVariant
+//otherwise variants are checked from the most specific one, and the first variant found in either set decides
+func (j ImplicitComparator) matchVariant(set map[string]struct{}, exclude map[string]struct{}, node string, buf []byte) (bool, []byte) {
+	if len(exclude) == 0 {
+		return j.hasVariant(set, node, buf)
+	}
+	for _, v := range j.specificVariants(j.Normalizer.normalize(node)) {
+		if _, ok := exclude[v]; ok {
+			return false, buf
+		}
+		if _, ok := set[v]; ok {
+			return true, buf
+		}
+	}
+	return false, buf
+}
+
+//hasVariant checks if any variant generateVariant would produce for node is in set
+//it walks the node in place using buf as scratch space instead of allocating every variant
+//the grown buf is returned so it can be reused for the next node
+func (j ImplicitComparator) hasVariant(set map[string]struct{}, node string, buf []byte) (bool, []byte) {
+	node = j.Normalizer.normalize(node)
+	if j.IncludeTerminator {
+		if _, ok := set[j.Terminator]; ok {
+			return true, buf
+		}
+	}
+	if _, ok := set[node]; ok {
+		return true, buf
+	}
+	if j.Deliminator == "" {
+		//splitting on nothing splits per character, leave that rare case to generateVariant
+		for _, v := range j.generateVariant(node) {
+			if _, ok := set[v]; ok {
+				return true, buf
+			}
+		}
+		return false, buf
+	}
+	end := 0
+	for {
+		i := strings.Index(node[end:], j.Deliminator)
+		last := i < 0
+		if last {
+			end = len(node)
+		} else {
+			end += i
+		}
+		if !(last && j.Terminator == "") {
+			buf = append(buf[:0], node[:end]...)
+			buf = append(buf, j.Terminator...)
+			if _, ok := set[string(buf)]; ok {
+				return true, buf
+			}
+		}
+		if last {
+			return false, buf
+		}
+		end += len(j.Deliminator)
+	}
+}
+
+//newNodeSet builds a lookup set out of nodes
+func newNodeSet(nodes []string) map[string]struct{} {
+	set := make(map[string]struct{}, len(nodes))
+	for _, n := range nodes {
+		set[n] = struct{}{}
+	}
+	return set
+}
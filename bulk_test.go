@@ -0,0 +1,173 @@
+package roller
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestExplicitComparator_Bulk(t *testing.T) {
+	tests := []struct {
+		name       string
+		permission []string
+		nodes      []string
+		wantAll    bool
+		wantAny    bool
+		wantFilter []string
+	}{
+		{
+			name:       "Empty",
+			permission: nil,
+			nodes:      nil,
+			wantAll:    true,
+			wantAny:    false,
+			wantFilter: []string{},
+		}, {
+			name:       "All",
+			permission: []string{"foo.bar", "foo.baz", "far"},
+			nodes:      []string{"far", "foo.bar"},
+			wantAll:    true,
+			wantAny:    true,
+			wantFilter: []string{"far", "foo.bar"},
+		}, {
+			name:       "Some",
+			permission: []string{"foo.bar", "far"},
+			nodes:      []string{"foo", "foo.bar", "faz", "far"},
+			wantAll:    false,
+			wantAny:    true,
+			wantFilter: []string{"foo.bar", "far"},
+		}, {
+			name:       "None",
+			permission: []string{"foo.bar"},
+			nodes:      []string{"foo", "foo.", "bar"},
+			wantAll:    false,
+			wantAny:    false,
+			wantFilter: []string{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			j := ExplicitComparator{}
+			a := assert.New(t)
+			l := List{Permission: tt.permission}
+			a.Equal(tt.wantAll, j.HasAll(l, tt.nodes...))
+			a.Equal(tt.wantAny, j.HasAny(l, tt.nodes...))
+			a.Equal(tt.wantFilter, j.Filter(l, tt.nodes))
+		})
+	}
+}
+
+func TestImplicitComparator_Bulk(t *testing.T) {
+	tests := []struct {
+		name       string
+		comparator ImplicitComparator
+		permission []string
+		nodes      []string
+		wantAll    bool
+		wantAny    bool
+		wantFilter []string
+	}{
+		{
+			name:       "Empty",
+			comparator: ImplicitComparator{Deliminator: ".", Terminator: "*"},
+			nodes:      nil,
+			wantAll:    true,
+			wantAny:    false,
+			wantFilter: []string{},
+		}, {
+			name:       "Wildcard",
+			comparator: ImplicitComparator{Deliminator: ".", Terminator: "*"},
+			permission: []string{"foo*", "bar.baz"},
+			nodes:      []string{"foo.bar", "foo.bar.baz", "bar.baz"},
+			wantAll:    true,
+			wantAny:    true,
+			wantFilter: []string{"foo.bar", "foo.bar.baz", "bar.baz"},
+		}, {
+			name:       "Missing wildcard",
+			comparator: ImplicitComparator{Deliminator: ".", Terminator: "*"},
+			permission: []string{"foo", "bar.baz*"},
+			nodes:      []string{"foo.bar", "bar.baz.buz", "bar"},
+			wantAll:    false,
+			wantAny:    true,
+			wantFilter: []string{"bar.baz.buz"},
+		}, {
+			name:       "Implicit",
+			comparator: ImplicitComparator{Deliminator: "."},
+			permission: []string{"foo"},
+			nodes:      []string{"foo.bar", "fo", "foo"},
+			wantAll:    false,
+			wantAny:    true,
+			wantFilter: []string{"foo.bar", "foo"},
+		}, {
+			name:       "Include terminator",
+			comparator: ImplicitComparator{Deliminator: ".", Terminator: "*", IncludeTerminator: true},
+			permission: []string{"*"},
+			nodes:      []string{"foo.bar", "baz"},
+			wantAll:    true,
+			wantAny:    true,
+			wantFilter: []string{"foo.bar", "baz"},
+		}, {
+			name:       "Multi character deliminator",
+			comparator: ImplicitComparator{Deliminator: "::", Terminator: "*"},
+			permission: []string{"foo::bar*"},
+			nodes:      []string{"foo::bar::baz", "foo::baz", "foo.bar"},
+			wantAll:    false,
+			wantAny:    true,
+			wantFilter: []string{"foo::bar::baz"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			j := tt.comparator
+			a := assert.New(t)
+			l := List{Permission: tt.permission}
+			a.Equal(tt.wantAll, j.HasAll(l, tt.nodes...))
+			a.Equal(tt.wantAny, j.HasAny(l, tt.nodes...))
+			a.Equal(tt.wantFilter, j.Filter(l, tt.nodes))
+		})
+	}
+}
+
+func TestImplicitComparator_hasVariant(t *testing.T) {
+	comparators := []ImplicitComparator{
+		{Deliminator: ".", Terminator: "*"},
+		{Deliminator: ".", Terminator: "*", IncludeTerminator: true},
+		{Deliminator: "."},
+		{Deliminator: ".", IncludeTerminator: true},
+		{Deliminator: ""},
+	}
+	nodes := []string{"", ".", "foo", "foo.bar.baz", "foo..bar", "foo.bar.", ".foo", "foo.*"}
+	for _, j := range comparators {
+		for _, n := range nodes {
+			a := assert.New(t)
+			//every variant generateVariant produce should be found by hasVariant on its own
+			for _, v := range j.generateVariant(n) {
+				ok, _ := j.hasVariant(newNodeSet([]string{v}), n, nil)
+				a.True(ok, "variant %q of %q missed by %+v", v, n, j)
+			}
+			ok, _ := j.hasVariant(newNodeSet([]string{n + "?"}), n, nil)
+			a.False(ok, "unexpected match for %q by %+v", n, j)
+		}
+	}
+}
+
+func BenchmarkImplicitComparator_HasPermission(b *testing.B) {
+	j := ImplicitComparator{Deliminator: ".", Terminator: "*"}
+	l := List{Permission: randSlice(200, 15)}
+	nodes := append(randNeedles(l.Permission, 25), randSlice(25, 16)...)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for _, n := range nodes {
+			j.HasPermission(l, n)
+		}
+	}
+}
+
+func BenchmarkImplicitComparator_Filter(b *testing.B) {
+	j := ImplicitComparator{Deliminator: ".", Terminator: "*"}
+	l := List{Permission: randSlice(200, 15)}
+	nodes := append(randNeedles(l.Permission, 25), randSlice(25, 16)...)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		j.Filter(l, nodes)
+	}
+}
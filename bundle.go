@@ -0,0 +1,88 @@
+package roller
+
+//bundleProvider returns the BundleProvider in use, or nil if there's none
+func (p BasicProcessor) bundleProvider() BundleProvider {
+	if p.Bundles != nil {
+		return p.Bundles
+	}
+	if bp, ok := p.Provider.(BundleProvider); ok {
+		return bp
+	}
+	return nil
+}
+
+//ExpandEntry returns a copy of Entry with every Entry.Include expanded into Grant and Revoke
+//nodes of included bundles come before the nodes of the Entry itself, in the order they are included
+//a bundle included more than once is only expanded once
+//returns UnknownBundleError if a bundle can't be loaded, or BundleCycleError if bundles include each other
+func (p BasicProcessor) ExpandEntry(e Entry) (Entry, error) {
+	if len(e.Include) == 0 {
+		return e, nil
+	}
+	x := bundleExpander{
+		provider: p.bundleProvider(),
+		done:     make(map[string]struct{}),
+		visiting: make(map[string]struct{}),
+	}
+	for _, name := range e.Include {
+		if err := x.expand(name); err != nil {
+			return Entry{}, err
+		}
+	}
+	o := e
+	o.Include = nil
+	o.Grant = append(x.grant, e.Grant...)
+	o.Revoke = append(x.revoke, e.Revoke...)
+	return o, nil
+}
+
+//bundleExpander collects nodes of bundles depth first
+type bundleExpander struct {
+	provider BundleProvider
+	//done are bundles that were fully expanded
+	done map[string]struct{}
+	//visiting and path are bundles being expanded, used for cycle detection
+	visiting map[string]struct{}
+	path     []string
+	grant    []string
+	revoke   []string
+}
+
+func (x *bundleExpander) expand(name string) error {
+	if _, ok := x.done[name]; ok {
+		return nil
+	}
+	if _, ok := x.visiting[name]; ok {
+		start := 0
+		for i, n := range x.path {
+			if n == name {
+				start = i
+				break
+			}
+		}
+		cycle := append(append([]string{}, x.path[start:]...), name)
+		return NewBundleCycleError(cycle)
+	}
+	if x.provider == nil {
+		return NewUnknownBundleError(name, nil)
+	}
+	b, err := x.provider.Bundle(name)
+	if err != nil {
+		return NewUnknownBundleError(name, err)
+	}
+
+	x.visiting[name] = struct{}{}
+	x.path = append(x.path, name)
+	for _, inc := range b.Include {
+		if err := x.expand(inc); err != nil {
+			return err
+		}
+	}
+	x.path = x.path[:len(x.path)-1]
+	delete(x.visiting, name)
+
+	x.done[name] = struct{}{}
+	x.grant = append(x.grant, b.Grant...)
+	x.revoke = append(x.revoke, b.Revoke...)
+	return nil
+}
@@ -0,0 +1,139 @@
+package roller
+
+import (
+	"errors"
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+//dummyBundles is a BundleProvider backed by a map
+type dummyBundles map[string]Bundle
+
+func (d dummyBundles) Bundle(name string) (Bundle, error) {
+	b, ok := d[name]
+	if !ok {
+		return Bundle{}, errors.New(fmt.Sprintf("bundle \"%s\" is not defined", name))
+	}
+	return b, nil
+}
+
+//dummyBundleProvider is a provider of both groups and bundles
+type dummyBundleProvider struct {
+	dummyProvider
+	dummyBundles
+}
+
+func testBundles() dummyBundles {
+	return dummyBundles{
+		"chat":    {Name: "chat", Grant: []string{"chat.send", "chat.read"}},
+		"emote":   {Name: "emote", Grant: []string{"chat.emote"}},
+		"social":  {Name: "social", Include: []string{"chat", "emote"}, Grant: []string{"friend.add"}},
+		"muted":   {Name: "muted", Revoke: []string{"chat.send"}},
+		"loop1":   {Name: "loop1", Include: []string{"loop2"}},
+		"loop2":   {Name: "loop2", Include: []string{"loop3"}},
+		"loop3":   {Name: "loop3", Include: []string{"loop1"}},
+		"self":    {Name: "self", Include: []string{"self"}},
+		"broken":  {Name: "broken", Include: []string{"missing"}},
+		"diamond": {Name: "diamond", Include: []string{"social", "chat"}},
+	}
+}
+
+func TestBasicProcessor_ExpandEntry(t *testing.T) {
+	p := BasicProcessor{Bundles: testBundles()}
+	tests := []struct {
+		name      string
+		e         Entry
+		want      Entry
+		wantCycle []string
+		wantErr   string
+	}{
+		{
+			name: "No include",
+			e:    Entry{Level: 1, Grant: []string{"a"}},
+			want: Entry{Level: 1, Grant: []string{"a"}},
+		}, {
+			name: "Nested",
+			e:    Entry{Level: 2, Include: []string{"social"}, Grant: []string{"a"}, Revoke: []string{"b"}},
+			want: Entry{Level: 2, Grant: []string{"chat.send", "chat.read", "chat.emote", "friend.add", "a"}, Revoke: []string{"b"}},
+		}, {
+			name: "Revoke",
+			e:    Entry{Include: []string{"muted"}, Revoke: []string{"chat.read"}},
+			want: Entry{Revoke: []string{"chat.send", "chat.read"}},
+		}, {
+			name: "Included twice",
+			e:    Entry{Include: []string{"diamond", "chat"}},
+			want: Entry{Grant: []string{"chat.send", "chat.read", "chat.emote", "friend.add"}},
+		}, {
+			name:      "Cycle",
+			e:         Entry{Include: []string{"chat", "loop1"}},
+			wantCycle: []string{"loop1", "loop2", "loop3", "loop1"},
+		}, {
+			name:      "Self cycle",
+			e:         Entry{Include: []string{"self"}},
+			wantCycle: []string{"self", "self"},
+		}, {
+			name:    "Unknown",
+			e:       Entry{Include: []string{"chat", "unknown"}},
+			wantErr: "unknown",
+		}, {
+			name:    "Unknown nested",
+			e:       Entry{Include: []string{"broken"}},
+			wantErr: "missing",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := assert.New(t)
+			got, err := p.ExpandEntry(tt.e)
+			switch {
+			case tt.wantCycle != nil:
+				var e BundleCycleError
+				a.ErrorAs(err, &e)
+				a.Equal(tt.wantCycle, e.Cycle())
+			case tt.wantErr != "":
+				var e UnknownBundleError
+				a.ErrorAs(err, &e)
+				a.Equal(tt.wantErr, e.Bundle())
+				a.Error(errors.Unwrap(e))
+			default:
+				a.NoError(err)
+				a.Equal(tt.want, got)
+			}
+		})
+	}
+}
+
+func TestBasicProcessor_Bundles(t *testing.T) {
+	a := assert.New(t)
+	groups := dummyProvider{groups: []Group{
+		{ID: "member", Weight: 1, Permission: Entry{Level: 1, Include: []string{"social"}}},
+		{ID: "muted", Weight: 2, Permission: Entry{Include: []string{"muted"}}},
+		{ID: "looped", Weight: 3, Permission: Entry{Include: []string{"loop2"}}},
+	}}
+
+	p := BasicProcessor{Provider: &dummyBundleProvider{dummyProvider: groups, dummyBundles: testBundles()}}
+	got, err := p.Process(RawList{Groups: []string{"member", "muted"}, Overwrites: Entry{Include: []string{"emote"}}})
+	a.NoError(err)
+	a.Equal([]string{"chat.read", "chat.emote", "friend.add"}, got.Permission)
+
+	_, err = p.Process(RawList{Groups: []string{"member", "looped"}})
+	var ce BundleCycleError
+	a.ErrorAs(err, &ce)
+	a.Equal("bundle include cycle: loop2 -> loop3 -> loop1 -> loop2", ce.Error())
+
+	_, err = p.ProcessFlags(RawList{Flags: map[string]FlagEntry{"f": {Entry: Entry{Include: []string{"nope"}}}}}, "f")
+	var ue UnknownBundleError
+	a.ErrorAs(err, &ue)
+	a.Equal("nope", ue.Bundle())
+
+	p = BasicProcessor{Provider: &groups}
+	_, err = p.Process(RawList{Groups: []string{"member"}})
+	a.ErrorAs(err, &ue)
+	a.Equal(`unknown bundle "social": no bundle provider`, ue.Error())
+
+	p.Bundles = testBundles()
+	got, err = p.Process(RawList{Groups: []string{"member"}})
+	a.NoError(err)
+	a.Equal([]string{"chat.send", "chat.read", "chat.emote", "friend.add"}, got.Permission)
+}
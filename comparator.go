@@ -7,13 +7,21 @@ type Comparator interface {
 	//HasPermission returns true if the List given has said permission node
 	HasPermission(p List, node string) bool
 	//HasPermissionWithLevel returns true if List has a permission, and also met the level requirement
-	//comparison method using >= or > is up to implementor
+	//comparison method using >= or > is up to implementor, see LevelPolicy
 	HasPermissionWithLevel(p List, node string, level int) bool
 	//IsHigherLevel compares if source List is higher then subject List
 	//returns true if source is higher
 	IsHigherLevel(source List, subject List) bool
 }
 
+//PatternComparator is a Comparator that treats granted nodes as patterns, such as GlobComparator
+//a pattern held by a List may match a more general pattern as a node, so HasPermission can't tell if a pattern is held
+type PatternComparator interface {
+	Comparator
+	//Covers checks if List holds the pattern itself, or patterns that match everything the pattern matches
+	Covers(p List, pattern string) bool
+}
+
 //SelfComparator an interface for something that's capable of comparing itself
 //by holding it's own List and Comparator
 //this is not used anywhere in the lib except to serve as an generic interface that can be used in other libraries
@@ -23,33 +31,86 @@ type SelfComparator interface {
 	IsHigherLevel(subject List) bool
 }
 
+//LevelPolicy decides if the source level is considered higher than the target level
+//it is used by comparators for both HasPermissionWithLevel and IsHigherLevel
+//a nil LevelPolicy behaves as LevelGreater
+type LevelPolicy func(source int, target int) bool
+
+//LevelGreater is a LevelPolicy that requires source to be strictly greater than target
+func LevelGreater(source int, target int) bool {
+	return source > target
+}
+
+//LevelGreaterOrEqual is a LevelPolicy that requires source to be greater or equal to target
+func LevelGreaterOrEqual(source int, target int) bool {
+	return source >= target
+}
+
+//meets returns true if source is higher than target according to the policy
+func (f LevelPolicy) meets(source int, target int) bool {
+	if f == nil {
+		return LevelGreater(source, target)
+	}
+	return f(source, target)
+}
+
+//levelFor returns the most specific scoped level of List that applies to node
+//a key applies if it equals node, or if it's a parent of node separated by deliminator
+//falls back to List.Level when no scoped level applies
+//an empty deliminator only allows exact matches
+func levelFor(p List, node string, deliminator string) int {
+	if len(p.Levels) == 0 {
+		return p.Level
+	}
+	if v, ok := p.Levels[node]; ok {
+		return v
+	}
+	if deliminator == "" {
+		return p.Level
+	}
+	for end := strings.LastIndex(node, deliminator); end >= 0; end = strings.LastIndex(node[:end], deliminator) {
+		if v, ok := p.Levels[node[:end]]; ok {
+			return v
+		}
+	}
+	return p.Level
+}
+
 //Insures that ExplicitComparator is Comparator
 var _ Comparator = (*ExplicitComparator)(nil)
 
 //ExplicitComparator is an explicit comparator
 //it will only match if exact permission node is present
 type ExplicitComparator struct {
+	//LevelPolicy decides how levels are compared, defaults to LevelGreater
+	LevelPolicy LevelPolicy
+	//Normalizer is applied to both the checked node and the List permissions before comparing
+	//defaults to exact matching
+	Normalizer Normalizer
 }
 
 //HasPermission checks if List has the exact node
 func (j ExplicitComparator) HasPermission(p List, node string) bool {
+	node = j.Normalizer.normalize(node)
 	for _, n := range p.Permission {
-		if n == node {
+		if j.Normalizer.normalize(n) == node {
 			return true
 		}
 	}
 	return false
 }
 
+//HasPermissionWithLevel checks if List has the exact node and meets the level
+//only a scoped level keyed by the exact node is used over List.Level
 func (j ExplicitComparator) HasPermissionWithLevel(p List, node string, level int) bool {
-	if p.Level <= level {
+	if !j.LevelPolicy.meets(levelFor(p, j.Normalizer.normalize(node), ""), level) {
 		return false
 	}
 	return j.HasPermission(p, node)
 }
 
 func (j ExplicitComparator) IsHigherLevel(source List, subject List) bool {
-	return source.Level > subject.Level
+	return j.LevelPolicy.meets(source.Level, subject.Level)
 }
 
 //Insure ImplicitComparator is Comparator
@@ -71,15 +132,27 @@ type ImplicitComparator struct {
 	Terminator string
 	//IncludeTerminator dictates if the terminal alone is a valid match
 	IncludeTerminator bool
+	//LevelPolicy decides how levels are compared, defaults to LevelGreater
+	LevelPolicy LevelPolicy
+	//Normalizer is applied to both the checked node and the List permissions before comparing
+	//defaults to exact matching
+	Normalizer Normalizer
 }
 
 //HasPermission checks if a list has a certain permission
 //Checking for foo.bar will result in variations of parent node to be generated and checked against
 //If node is foo.bar, it would check if list has foo*(grant recursively) or foo.bar* or foo.bar(non-recursive grant)
+//If the list has any List.Exclude, the most specific variant found in either List.Permission or List.Exclude decides
 func (j ImplicitComparator) HasPermission(p List, node string) bool {
-	v := j.generateVariant(node)
+	if len(p.Exclude) > 0 {
+		ok, _ := j.matchVariant(newNodeSet(j.Normalizer.normalizeAll(p.Permission)),
+			newNodeSet(j.Normalizer.normalizeAll(p.Exclude)), node, nil)
+		return ok
+	}
+	v := j.generateVariant(j.Normalizer.normalize(node))
+	ps := j.Normalizer.normalizeAll(p.Permission)
 	for _, sv := range v {
-		for _, n := range p.Permission {
+		for _, n := range ps {
 			if n == sv {
 				return true
 			}
@@ -88,15 +161,38 @@ func (j ImplicitComparator) HasPermission(p List, node string) bool {
 	return false
 }
 
+//HasPermissionWithLevel checks if List has the node and meets the level
+//the most specific scoped level whose key is the node or one of its parents is used over List.Level
 func (j ImplicitComparator) HasPermissionWithLevel(p List, node string, level int) bool {
-	if p.Level <= level {
+	if !j.LevelPolicy.meets(levelFor(p, j.Normalizer.normalize(node), j.Deliminator), level) {
 		return false
 	}
 	return j.HasPermission(p, node)
 }
 
 func (j ImplicitComparator) IsHigherLevel(source List, subject List) bool {
-	return source.Level > subject.Level
+	return j.LevelPolicy.meets(source.Level, subject.Level)
+}
+
+//specificVariants returns the same variants as generateVariant, ordered from the most specific to the least
+//for example foo.bar.baz will return:
+//foo.bar.baz, foo.bar.baz*, foo.bar*, foo*
+//followed by * if ImplicitComparator.IncludeTerminator is true
+func (j ImplicitComparator) specificVariants(str string) []string {
+	v := j.generateVariant(str)
+	start := 0
+	if j.IncludeTerminator {
+		start = 1
+	}
+	o := make([]string, 0, len(v))
+	o = append(o, str)
+	for i := len(v) - 1; i > start; i-- {
+		o = append(o, v[i])
+	}
+	if j.IncludeTerminator {
+		o = append(o, j.Terminator)
+	}
+	return o
 }
 
 //generateVariant takes in a permission node
@@ -596,3 +596,157 @@ func TestImplicitComparator_generateVariant(t *testing.T) {
 		})
 	}
 }
+
+func TestLevelPolicy_meets(t *testing.T) {
+	tests := []struct {
+		name   string
+		policy LevelPolicy
+		source int
+		target int
+		want   bool
+	}{
+		{name: "Default higher", source: 10, target: 5, want: true},
+		{name: "Default equal", source: 5, target: 5, want: false},
+		{name: "Default lower", source: 1, target: 5, want: false},
+		{name: "Greater higher", policy: LevelGreater, source: -4, target: -5, want: true},
+		{name: "Greater equal", policy: LevelGreater, source: -5, target: -5, want: false},
+		{name: "Greater or equal higher", policy: LevelGreaterOrEqual, source: 6, target: 5, want: true},
+		{name: "Greater or equal equal", policy: LevelGreaterOrEqual, source: 5, target: 5, want: true},
+		{name: "Greater or equal lower", policy: LevelGreaterOrEqual, source: 4, target: 5, want: false},
+		{
+			name: "Custom margin",
+			policy: func(source int, target int) bool {
+				return source-target >= 10
+			},
+			source: 14,
+			target: 5,
+			want:   false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := assert.New(t)
+			a.Equal(tt.want, tt.policy.meets(tt.source, tt.target))
+		})
+	}
+}
+
+func TestComparator_LevelPolicy(t *testing.T) {
+	comparators := map[string]func(policy LevelPolicy) Comparator{
+		"Explicit": func(policy LevelPolicy) Comparator {
+			return ExplicitComparator{LevelPolicy: policy}
+		},
+		"Implicit": func(policy LevelPolicy) Comparator {
+			return ImplicitComparator{Deliminator: ".", Terminator: "*", LevelPolicy: policy}
+		},
+	}
+	for name, c := range comparators {
+		t.Run(name, func(t *testing.T) {
+			a := assert.New(t)
+			l := List{Level: 5, Permission: []string{"foo.bar"}}
+
+			a.False(c(nil).HasPermissionWithLevel(l, "foo.bar", 5))
+			a.False(c(nil).IsHigherLevel(l, List{Level: 5}))
+
+			ge := c(LevelGreaterOrEqual)
+			a.True(ge.HasPermissionWithLevel(l, "foo.bar", 5))
+			a.False(ge.HasPermissionWithLevel(l, "foo.bar", 6))
+			a.False(ge.HasPermissionWithLevel(l, "foo.baz", 5))
+			a.True(ge.IsHigherLevel(l, List{Level: 5}))
+			a.False(ge.IsHigherLevel(l, List{Level: 6}))
+
+			never := c(func(source int, target int) bool {
+				return false
+			})
+			a.False(never.HasPermissionWithLevel(l, "foo.bar", -100))
+			a.False(never.IsHigherLevel(l, List{Level: -100}))
+		})
+	}
+}
+
+func TestLevelFor(t *testing.T) {
+	l := List{
+		Level: 1,
+		Levels: map[string]int{
+			"chat":            10,
+			"chat.moderate":   -5,
+			"world.build.all": 20,
+		},
+	}
+	tests := []struct {
+		name        string
+		list        List
+		node        string
+		deliminator string
+		want        int
+	}{
+		{name: "No scoped levels", list: List{Level: 3}, node: "chat.send", deliminator: ".", want: 3},
+		{name: "Exact", list: l, node: "chat", deliminator: ".", want: 10},
+		{name: "Parent", list: l, node: "chat.send", deliminator: ".", want: 10},
+		{name: "Most specific", list: l, node: "chat.moderate.kick", deliminator: ".", want: -5},
+		{name: "Not a segment", list: l, node: "chatter", deliminator: ".", want: 1},
+		{name: "Child key not applied to parent", list: l, node: "world.build", deliminator: ".", want: 1},
+		{name: "Fallback", list: l, node: "foo.bar", deliminator: ".", want: 1},
+		{name: "Exact only", list: l, node: "chat.send", deliminator: "", want: 1},
+		{name: "Exact only match", list: l, node: "chat.moderate", deliminator: "", want: -5},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := assert.New(t)
+			a.Equal(tt.want, levelFor(tt.list, tt.node, tt.deliminator))
+		})
+	}
+}
+
+func TestComparator_ScopedLevel(t *testing.T) {
+	a := assert.New(t)
+	l := List{
+		Level:      1,
+		Levels:     map[string]int{"chat": 10, "chat.moderate": 3},
+		Permission: []string{"chat*", "chat.send", "world"},
+	}
+	i := ImplicitComparator{Deliminator: ".", Terminator: "*"}
+	a.True(i.HasPermissionWithLevel(l, "chat.send", 5))
+	a.False(i.HasPermissionWithLevel(l, "chat.moderate.kick", 5))
+	a.True(i.HasPermissionWithLevel(l, "chat.moderate.kick", 2))
+	a.False(i.HasPermissionWithLevel(l, "world", 1))
+	a.True(i.HasPermissionWithLevel(l, "world", 0))
+
+	e := ExplicitComparator{}
+	a.False(e.HasPermissionWithLevel(l, "chat.send", 5))
+	a.True(e.HasPermissionWithLevel(l, "chat.send", 0))
+}
+
+func TestImplicitComparator_specificVariants(t *testing.T) {
+	tests := []struct {
+		name       string
+		comparator ImplicitComparator
+		str        string
+		want       []string
+	}{
+		{
+			name:       "Simple",
+			comparator: ImplicitComparator{Deliminator: ".", Terminator: "*"},
+			str:        "foo.bar.baz",
+			want:       []string{"foo.bar.baz", "foo.bar.baz*", "foo.bar*", "foo*"},
+		}, {
+			name:       "Include terminator",
+			comparator: ImplicitComparator{Deliminator: ".", Terminator: "*", IncludeTerminator: true},
+			str:        "foo.bar",
+			want:       []string{"foo.bar", "foo.bar*", "foo*", "*"},
+		}, {
+			name:       "No terminator",
+			comparator: ImplicitComparator{Deliminator: "."},
+			str:        "foo.bar.baz",
+			want:       []string{"foo.bar.baz", "foo.bar", "foo"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := assert.New(t)
+			got := tt.comparator.specificVariants(tt.str)
+			a.Equal(tt.want, got)
+			a.ElementsMatch(tt.comparator.generateVariant(tt.str), got)
+		})
+	}
+}
@@ -0,0 +1,195 @@
+package roller
+
+import "sort"
+
+//ListDiff is the difference between two List
+type ListDiff struct {
+	//Added are nodes only the new List has, in the order of the new List
+	Added []string
+	//Removed are nodes only the old List has, in the order of the old List
+	Removed []string
+	//AddedExclude and RemovedExclude are the same as Added and Removed, but for List.Exclude
+	AddedExclude   []string
+	RemovedExclude []string
+	//LevelDelta is the new List.Level subtracted by the old List.Level
+	LevelDelta int
+	//Levels are the changed scoped levels, keyed the same as List.Levels with the value being the delta
+	//a key only one List has is compared against 0
+	Levels map[string]int
+}
+
+//Empty returns true if there's no difference
+func (d ListDiff) Empty() bool {
+	return len(d.Added) == 0 && len(d.Removed) == 0 && len(d.AddedExclude) == 0 && len(d.RemovedExclude) == 0 &&
+		d.LevelDelta == 0 && len(d.Levels) == 0
+}
+
+//Diff returns the difference from List a to List b
+func Diff(a List, b List) ListDiff {
+	d := ListDiff{LevelDelta: b.Level - a.Level}
+	d.Added, d.Removed = diffNodes(a.Permission, b.Permission)
+	d.AddedExclude, d.RemovedExclude = diffNodes(a.Exclude, b.Exclude)
+	d.Levels = diffLevels(a.Levels, b.Levels)
+	return d
+}
+
+//EntryDiff is the difference between two Entry
+type EntryDiff struct {
+	//AddedGrant and RemovedGrant are the changes of Entry.Grant
+	AddedGrant   []string
+	RemovedGrant []string
+	//AddedRevoke and RemovedRevoke are the changes of Entry.Revoke
+	AddedRevoke   []string
+	RemovedRevoke []string
+	//AddedInclude and RemovedInclude are the changes of Entry.Include
+	AddedInclude   []string
+	RemovedInclude []string
+	//LevelDelta is the new Entry.Level subtracted by the old Entry.Level
+	LevelDelta int
+	//Levels are the changed scoped levels, see ListDiff.Levels
+	Levels map[string]int
+	//SetLevel and EmptySet are true if the respective field was changed
+	SetLevel bool
+	EmptySet bool
+}
+
+//Empty returns true if there's no difference
+func (d EntryDiff) Empty() bool {
+	return len(d.AddedGrant) == 0 && len(d.RemovedGrant) == 0 && len(d.AddedRevoke) == 0 && len(d.RemovedRevoke) == 0 &&
+		len(d.AddedInclude) == 0 && len(d.RemovedInclude) == 0 && d.LevelDelta == 0 && len(d.Levels) == 0 && !d.SetLevel && !d.EmptySet
+}
+
+//FlagDiff is the difference between two FlagEntry
+type FlagDiff struct {
+	EntryDiff
+	//WeightDelta is the new FlagEntry.Weight subtracted by the old FlagEntry.Weight
+	WeightDelta int
+	//Preprocess is true if FlagEntry.Preprocess was changed
+	Preprocess bool
+}
+
+//Empty returns true if there's no difference
+func (d FlagDiff) Empty() bool {
+	return d.EntryDiff.Empty() && d.WeightDelta == 0 && !d.Preprocess
+}
+
+//GroupDiff is the difference between two Group
+//display only fields such as Group.Name, and Group.Revision are not compared
+type GroupDiff struct {
+	//Permission is the difference of Group.Permission
+	Permission EntryDiff
+	//Flags are the differences of every changed flag, keyed by flag name
+	//added and removed flags are compared against a zero FlagEntry
+	Flags map[string]FlagDiff
+	//AddedFlags and RemovedFlags are the sorted names of flags only one Group has
+	AddedFlags   []string
+	RemovedFlags []string
+	//WeightDelta is the new Group.Weight subtracted by the old Group.Weight
+	WeightDelta int
+	//Track is true if Group.Track was changed
+	Track bool
+}
+
+//Empty returns true if there's no difference
+func (d GroupDiff) Empty() bool {
+	return d.Permission.Empty() && len(d.Flags) == 0 && d.WeightDelta == 0 && !d.Track
+}
+
+//DiffGroup returns the difference from Group a to Group b
+func DiffGroup(a Group, b Group) GroupDiff {
+	d := GroupDiff{
+		Permission:  diffEntry(a.Permission, b.Permission),
+		WeightDelta: b.Weight - a.Weight,
+		Track:       a.Track != b.Track,
+	}
+	for n, bf := range b.Flags {
+		af, ok := a.Flags[n]
+		if !ok {
+			d.AddedFlags = append(d.AddedFlags, n)
+		}
+		fd := FlagDiff{
+			EntryDiff:   diffEntry(af.Entry, bf.Entry),
+			WeightDelta: bf.Weight - af.Weight,
+			Preprocess:  af.Preprocess != bf.Preprocess,
+		}
+		if !ok || !fd.Empty() {
+			if d.Flags == nil {
+				d.Flags = make(map[string]FlagDiff)
+			}
+			d.Flags[n] = fd
+		}
+	}
+	for n, af := range a.Flags {
+		if _, ok := b.Flags[n]; ok {
+			continue
+		}
+		d.RemovedFlags = append(d.RemovedFlags, n)
+		if d.Flags == nil {
+			d.Flags = make(map[string]FlagDiff)
+		}
+		d.Flags[n] = FlagDiff{
+			EntryDiff:   diffEntry(af.Entry, Entry{}),
+			WeightDelta: -af.Weight,
+			Preprocess:  af.Preprocess,
+		}
+	}
+	sort.Strings(d.AddedFlags)
+	sort.Strings(d.RemovedFlags)
+	return d
+}
+
+//diffEntry returns the difference from Entry a to Entry b
+func diffEntry(a Entry, b Entry) EntryDiff {
+	d := EntryDiff{
+		LevelDelta: b.Level - a.Level,
+		Levels:     diffLevels(a.Levels, b.Levels),
+		SetLevel:   a.SetLevel != b.SetLevel,
+		EmptySet:   a.EmptySet != b.EmptySet,
+	}
+	d.AddedGrant, d.RemovedGrant = diffNodes(a.Grant, b.Grant)
+	d.AddedRevoke, d.RemovedRevoke = diffNodes(a.Revoke, b.Revoke)
+	d.AddedInclude, d.RemovedInclude = diffNodes(a.Include, b.Include)
+	return d
+}
+
+//diffNodes returns nodes only b has and nodes only a has
+func diffNodes(a []string, b []string) (added []string, removed []string) {
+	//a node is put into the other set once it's reported, so duplicates are only reported once
+	as, bs := newNodeSet(a), newNodeSet(b)
+	for _, n := range b {
+		if _, ok := as[n]; !ok {
+			added = append(added, n)
+			as[n] = struct{}{}
+		}
+	}
+	for _, n := range a {
+		if _, ok := bs[n]; !ok {
+			removed = append(removed, n)
+			bs[n] = struct{}{}
+		}
+	}
+	return added, removed
+}
+
+//diffLevels returns the delta of every changed scoped level, or nil if none changed
+func diffLevels(a map[string]int, b map[string]int) map[string]int {
+	var d map[string]int
+	set := func(k string, v int) {
+		if d == nil {
+			d = make(map[string]int)
+		}
+		d[k] = v
+	}
+	for k, bv := range b {
+		av, ok := a[k]
+		if !ok || av != bv {
+			set(k, bv-av)
+		}
+	}
+	for k, av := range a {
+		if _, ok := b[k]; !ok {
+			set(k, -av)
+		}
+	}
+	return d
+}
@@ -0,0 +1,88 @@
+package roller
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestDiff(t *testing.T) {
+	tests := []struct {
+		name string
+		a    List
+		b    List
+		want ListDiff
+	}{
+		{
+			name: "Empty",
+			a:    List{Level: 1, Permission: []string{"a", "b"}},
+			b:    List{Level: 1, Permission: []string{"b", "a"}},
+			want: ListDiff{},
+		}, {
+			name: "Nodes",
+			a:    List{Permission: []string{"a", "b", "c"}, Exclude: []string{"x.y"}},
+			b:    List{Permission: []string{"d", "b", "e", "d"}, Exclude: []string{"x.z"}},
+			want: ListDiff{
+				Added: []string{"d", "e"}, Removed: []string{"a", "c"},
+				AddedExclude: []string{"x.z"}, RemovedExclude: []string{"x.y"},
+			},
+		}, {
+			name: "Levels",
+			a:    List{Level: 5, Levels: map[string]int{"chat": 3, "world": 2, "same": 1}},
+			b:    List{Level: 2, Levels: map[string]int{"chat": 7, "admin": 4, "same": 1}},
+			want: ListDiff{LevelDelta: -3, Levels: map[string]int{"chat": 4, "admin": 4, "world": -2}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := assert.New(t)
+			got := Diff(tt.a, tt.b)
+			a.Equal(tt.want, got)
+			a.Equal(tt.want.Empty(), got.Empty())
+		})
+	}
+	assert.True(t, Diff(List{}, List{}).Empty())
+}
+
+func TestDiffGroup(t *testing.T) {
+	a := assert.New(t)
+	old := Group{
+		ID: "g", Name: "old", Weight: 1,
+		Permission: Entry{Level: 2, Grant: []string{"a", "b"}, Revoke: []string{"r"}, Include: []string{"chat"}},
+		Flags: map[string]FlagEntry{
+			"same":    {Weight: 1, Entry: Entry{Grant: []string{"s"}}},
+			"changed": {Weight: 1, Entry: Entry{Level: 1}},
+			"removed": {Weight: 3, Preprocess: true, Entry: Entry{Grant: []string{"x"}}},
+		},
+	}
+	nw := Group{
+		ID: "g", Name: "new", Weight: 4, Track: "staff",
+		Permission: Entry{Level: 2, SetLevel: true, Levels: map[string]int{"chat": 1}, Grant: []string{"b", "c"}, Include: []string{"social"}},
+		Flags: map[string]FlagEntry{
+			"same":    {Weight: 1, Entry: Entry{Grant: []string{"s"}}},
+			"changed": {Weight: 2, Entry: Entry{Level: 1, EmptySet: true}},
+			"added":   {Weight: 5},
+		},
+	}
+	got := DiffGroup(old, nw)
+	a.Equal(GroupDiff{
+		Permission: EntryDiff{
+			AddedGrant: []string{"c"}, RemovedGrant: []string{"a"}, RemovedRevoke: []string{"r"},
+			AddedInclude: []string{"social"}, RemovedInclude: []string{"chat"},
+			Levels: map[string]int{"chat": 1}, SetLevel: true,
+		},
+		Flags: map[string]FlagDiff{
+			"changed": {EntryDiff: EntryDiff{EmptySet: true}, WeightDelta: 1},
+			"added":   {WeightDelta: 5},
+			"removed": {EntryDiff: EntryDiff{RemovedGrant: []string{"x"}}, WeightDelta: -3, Preprocess: true},
+		},
+		AddedFlags:   []string{"added"},
+		RemovedFlags: []string{"removed"},
+		WeightDelta:  3,
+		Track:        true,
+	}, got)
+	a.False(got.Empty())
+
+	old.Name = "renamed"
+	a.True(DiffGroup(old, old).Empty())
+	a.Equal(GroupDiff{}, DiffGroup(old, old))
+}
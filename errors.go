@@ -1,6 +1,9 @@
 package roller
 
-import "fmt"
+import (
+	"fmt"
+	"strings"
+)
 
 var _ error = (*MissingGroupError)(nil) // ensure MissingGroupError implements error
 
@@ -28,3 +31,264 @@ func (e MissingGroupError) Unwrap() error {
 func (e MissingGroupError) Group() string {
 	return e.group
 }
+
+var _ error = (*InvalidPatternError)(nil) // ensure InvalidPatternError implements error
+
+//InvalidPatternError is an error raised by GlobComparator.Compile when a granted node isn't a valid pattern
+type InvalidPatternError struct {
+	pattern string
+	error   error
+}
+
+func NewInvalidPatternError(pattern string, err error) InvalidPatternError {
+	return InvalidPatternError{
+		pattern: pattern,
+		error:   err,
+	}
+}
+
+func (e InvalidPatternError) Error() string {
+	return fmt.Sprintf("invalid pattern \"%v\": %v", e.Pattern(), e.error)
+}
+
+func (e InvalidPatternError) Unwrap() error {
+	return e.error
+}
+
+func (e InvalidPatternError) Pattern() string {
+	return e.pattern
+}
+
+var _ error = (*WeightConflictError)(nil) // ensure WeightConflictError implements error
+
+//WeightConflictError is an error raised by BasicProcessor when BasicProcessor.StrictWeight is set
+//and multiple groups, or multiple selected flags of the same owner share a weight
+type WeightConflictError struct {
+	weight int
+	flag   bool
+	owner  string
+	names  []string
+}
+
+//NewGroupWeightConflictError creates a WeightConflictError for groups, ids are the clashing group IDs
+func NewGroupWeightConflictError(weight int, ids []string) WeightConflictError {
+	return WeightConflictError{
+		weight: weight,
+		names:  ids,
+	}
+}
+
+//NewFlagWeightConflictError creates a WeightConflictError for flags
+//owner is the group ID the flags belong to, or "" for RawList flags, names are the clashing flag names
+func NewFlagWeightConflictError(weight int, owner string, names []string) WeightConflictError {
+	return WeightConflictError{
+		weight: weight,
+		flag:   true,
+		owner:  owner,
+		names:  names,
+	}
+}
+
+func (e WeightConflictError) Error() string {
+	names := make([]string, 0, len(e.names))
+	for _, n := range e.names {
+		names = append(names, fmt.Sprintf("\"%v\"", n))
+	}
+	if !e.flag {
+		return fmt.Sprintf("groups %v share weight %v", strings.Join(names, ", "), e.weight)
+	}
+	if e.owner == "" {
+		return fmt.Sprintf("raw list flags %v share weight %v", strings.Join(names, ", "), e.weight)
+	}
+	return fmt.Sprintf("flags %v of group \"%v\" share weight %v", strings.Join(names, ", "), e.owner, e.weight)
+}
+
+//Weight returns the shared weight
+func (e WeightConflictError) Weight() int {
+	return e.weight
+}
+
+//Flag returns true if the conflict is between flags instead of groups
+func (e WeightConflictError) Flag() bool {
+	return e.flag
+}
+
+//Owner returns the group ID the clashing flags belong to, it's "" for groups and RawList flags
+func (e WeightConflictError) Owner() string {
+	return e.owner
+}
+
+//Names returns the clashing group IDs or flag names
+func (e WeightConflictError) Names() []string {
+	return e.names
+}
+
+var _ error = (*LadderEndError)(nil) // ensure LadderEndError implements error
+
+//LadderEndError is an error raised by Ladder when a RawList can't be promoted or demoted any further
+type LadderEndError struct {
+	group string
+	top   bool
+}
+
+func NewLadderEndError(group string, top bool) LadderEndError {
+	return LadderEndError{
+		group: group,
+		top:   top,
+	}
+}
+
+func (e LadderEndError) Error() string {
+	if e.top {
+		return fmt.Sprintf("group \"%v\" is at the top of the ladder", e.group)
+	}
+	if e.group == "" {
+		return "not on the ladder"
+	}
+	return fmt.Sprintf("group \"%v\" is at the bottom of the ladder", e.group)
+}
+
+//Group returns the current ladder group, it's "" if the RawList isn't on the ladder
+func (e LadderEndError) Group() string {
+	return e.group
+}
+
+//Top returns true if the top of the ladder is reached, false if it's the bottom
+func (e LadderEndError) Top() bool {
+	return e.top
+}
+
+var _ error = (*UnknownTrackError)(nil) // ensure UnknownTrackError implements error
+
+//UnknownTrackError is an error raised by LoadTrackLadder when no group is on the track
+type UnknownTrackError struct {
+	track string
+}
+
+func NewUnknownTrackError(track string) UnknownTrackError {
+	return UnknownTrackError{track: track}
+}
+
+func (e UnknownTrackError) Error() string {
+	return fmt.Sprintf("no group is on track \"%v\"", e.track)
+}
+
+func (e UnknownTrackError) Track() string {
+	return e.track
+}
+
+var _ error = (*IncompleteLadderError)(nil) // ensure IncompleteLadderError implements error
+
+//IncompleteLadderError is an error raised by Ladder.PromoteBy and Ladder.DemoteBy when a required field of Ladder isn't set
+type IncompleteLadderError struct {
+	field string
+}
+
+func NewIncompleteLadderError(field string) IncompleteLadderError {
+	return IncompleteLadderError{field: field}
+}
+
+func (e IncompleteLadderError) Error() string {
+	return fmt.Sprintf("ladder has no %v set", e.field)
+}
+
+//Field returns the name of the Ladder field that isn't set
+func (e IncompleteLadderError) Field() string {
+	return e.field
+}
+
+var _ error = (*InsufficientLevelError)(nil) // ensure InsufficientLevelError implements error
+
+//InsufficientLevelError is an error raised when an actor isn't higher level than its target
+type InsufficientLevelError struct {
+	actor  int
+	target int
+}
+
+func NewInsufficientLevelError(actor int, target int) InsufficientLevelError {
+	return InsufficientLevelError{
+		actor:  actor,
+		target: target,
+	}
+}
+
+func (e InsufficientLevelError) Error() string {
+	return fmt.Sprintf("actor level %v is not higher than target level %v", e.actor, e.target)
+}
+
+func (e InsufficientLevelError) Actor() int {
+	return e.actor
+}
+
+func (e InsufficientLevelError) Target() int {
+	return e.target
+}
+
+var _ error = (*UnknownBundleError)(nil) // ensure UnknownBundleError implements error
+
+//UnknownBundleError is an error raised by BasicProcessor when an included Bundle can't be loaded
+type UnknownBundleError struct {
+	bundle string
+	error  error
+}
+
+func NewUnknownBundleError(name string, err error) UnknownBundleError {
+	return UnknownBundleError{
+		bundle: name,
+		error:  err,
+	}
+}
+
+func (e UnknownBundleError) Error() string {
+	if e.error == nil {
+		return fmt.Sprintf("unknown bundle \"%v\": no bundle provider", e.Bundle())
+	}
+	return fmt.Sprintf("unknown bundle \"%v\": %v", e.Bundle(), e.error)
+}
+
+func (e UnknownBundleError) Unwrap() error {
+	return e.error
+}
+
+func (e UnknownBundleError) Bundle() string {
+	return e.bundle
+}
+
+var _ error = (*BundleCycleError)(nil) // ensure BundleCycleError implements error
+
+//BundleCycleError is an error raised by BasicProcessor when a Bundle includes itself, directly or through other bundles
+type BundleCycleError struct {
+	cycle []string
+}
+
+func NewBundleCycleError(cycle []string) BundleCycleError {
+	return BundleCycleError{cycle: cycle}
+}
+
+func (e BundleCycleError) Error() string {
+	return fmt.Sprintf("bundle include cycle: %v", strings.Join(e.cycle, " -> "))
+}
+
+//Cycle returns the bundle names forming the cycle, the first name is repeated at the end
+func (e BundleCycleError) Cycle() []string {
+	return e.cycle
+}
+
+var _ error = (*UnsupportedTenantError)(nil) // ensure UnsupportedTenantError implements error
+
+//UnsupportedTenantError is an error raised by BasicProcessor when a RawList has a tenant, but the provider isn't a TenantProvider
+type UnsupportedTenantError struct {
+	tenant string
+}
+
+func NewUnsupportedTenantError(tenant string) UnsupportedTenantError {
+	return UnsupportedTenantError{tenant: tenant}
+}
+
+func (e UnsupportedTenantError) Error() string {
+	return fmt.Sprintf("tenant \"%v\" is unsupported: provider is not a TenantProvider", e.Tenant())
+}
+
+func (e UnsupportedTenantError) Tenant() string {
+	return e.tenant
+}
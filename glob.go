@@ -0,0 +1,417 @@
+package roller
+
+import (
+	"container/list"
+	"errors"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+//Insure GlobComparator is PatternComparator
+var _ PatternComparator = (*GlobComparator)(nil)
+
+//GlobComparator is a pattern matching comparator
+//it treats every granted node as a glob pattern, split into segments by the Deliminator
+//supported syntax within a segment:
+//* matches any characters within one segment, for example reports.2024-*.read
+//? matches exactly one character
+//[abc], [a-z] and [!a-z] are character classes, [^a-z] is also accepted for negation
+//{a,b} matches either alternative, alternatives can't contain the Deliminator
+//\ escapes the next character
+//a segment that is exactly ** matches zero or more whole segments, for example tenant.**
+//
+//GlobComparator implements Comparator, but patterns are parsed on every call unless Cache is set, and invalid ones never match
+//use Compile to parse a List once into a GlobList and to surface invalid patterns as errors
+//List.Exclude is not respected, a revoked node carved out of a granted pattern still matches
+type GlobComparator struct {
+	//Deliminator is the character(s) that will be separating permission nodes
+	//setting deliminator to "" treats the whole node as one segment
+	Deliminator string
+	//LevelPolicy decides how levels are compared, defaults to LevelGreater
+	LevelPolicy LevelPolicy
+	//Normalizer is applied to both the checked node and the List permissions before matching
+	//patterns are normalized before being parsed, defaults to keeping nodes as is
+	Normalizer Normalizer
+	//Cache keeps parsed patterns between calls, nil parses patterns on every call
+	Cache *GlobCache
+}
+
+//globKey is the key of a parsed pattern inside GlobCache
+type globKey struct {
+	deliminator string
+	pattern     string
+}
+
+//globEntry is a parsed pattern inside GlobCache
+type globEntry struct {
+	key     globKey
+	pattern globPattern
+}
+
+//GlobCache is a bounded cache of patterns parsed by GlobComparator, the least recently used pattern is evicted first
+//invalid patterns are not cached, it's safe to be shared by multiple GlobComparator
+type GlobCache struct {
+	size    int
+	m       sync.Mutex
+	entries map[globKey]*list.Element
+	lru     *list.List
+}
+
+//NewGlobCache creates a GlobCache holding at most size patterns
+func NewGlobCache(size int) *GlobCache {
+	return &GlobCache{size: size, entries: make(map[globKey]*list.Element), lru: list.New()}
+}
+
+//Len returns the amount of cached patterns
+func (c *GlobCache) Len() int {
+	c.m.Lock()
+	defer c.m.Unlock()
+	return c.lru.Len()
+}
+
+func (c *GlobCache) load(key globKey) (globPattern, bool) {
+	c.m.Lock()
+	defer c.m.Unlock()
+	e, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+	c.lru.MoveToFront(e)
+	return e.Value.(*globEntry).pattern, true
+}
+
+func (c *GlobCache) store(key globKey, pattern globPattern) {
+	c.m.Lock()
+	defer c.m.Unlock()
+	if e, ok := c.entries[key]; ok {
+		c.lru.MoveToFront(e)
+		return
+	}
+	c.entries[key] = c.lru.PushFront(&globEntry{key: key, pattern: pattern})
+	for c.lru.Len() > c.size {
+		e := c.lru.Back()
+		c.lru.Remove(e)
+		delete(c.entries, e.Value.(*globEntry).key)
+	}
+}
+
+//Compile parses every permission of List into a GlobList
+//returns InvalidPatternError on the first pattern that can't be parsed
+func (g GlobComparator) Compile(p List) (GlobList, error) {
+	ps := make([]globPattern, 0, len(p.Permission))
+	for _, n := range p.Permission {
+		gp, err := g.cachedPattern(n)
+		if err != nil {
+			return GlobList{}, NewInvalidPatternError(n, err)
+		}
+		ps = append(ps, gp)
+	}
+	return GlobList{list: p, comparator: g, patterns: ps}, nil
+}
+
+//HasPermission checks if any permission of List matches the node
+//invalid patterns are ignored
+func (g GlobComparator) HasPermission(p List, node string) bool {
+	return g.compileLenient(p).HasPermission(node)
+}
+
+func (g GlobComparator) HasPermissionWithLevel(p List, node string, level int) bool {
+	return g.compileLenient(p).HasPermissionWithLevel(node, level)
+}
+
+func (g GlobComparator) IsHigherLevel(source List, subject List) bool {
+	return g.LevelPolicy.meets(source.Level, subject.Level)
+}
+
+//Covers checks if List holds the pattern, or a pattern matching everything the pattern matches
+//it's conservative, a pattern is only covered segment by segment:
+//a held ** covers any amount of segments, a held * covers any single segment, and other held segments only cover the same segment or a matching literal
+//invalid held patterns never cover
+func (g GlobComparator) Covers(p List, pattern string) bool {
+	pattern = g.Normalizer.normalize(pattern)
+	ps := g.split(pattern)
+	for _, n := range p.Permission {
+		n = g.Normalizer.normalize(n)
+		if n == pattern {
+			return true
+		}
+		if _, err := g.cachedPattern(n); err != nil {
+			continue
+		}
+		if coversSegments(g.split(n), ps) {
+			return true
+		}
+	}
+	return false
+}
+
+//split splits a node or pattern into segments by the Deliminator
+func (g GlobComparator) split(n string) []string {
+	if g.Deliminator == "" {
+		return []string{n}
+	}
+	return strings.Split(n, g.Deliminator)
+}
+
+//coversSegments checks if held segments match everything the pattern segments match
+func coversSegments(held []string, pattern []string) bool {
+	if len(held) == 0 {
+		return len(pattern) == 0
+	}
+	if held[0] == "**" {
+		for i := 0; i <= len(pattern); i++ {
+			if coversSegments(held[1:], pattern[i:]) {
+				return true
+			}
+		}
+		return false
+	}
+	if len(pattern) == 0 || pattern[0] == "**" {
+		return false
+	}
+	return coversSegment(held[0], pattern[0]) && coversSegments(held[1:], pattern[1:])
+}
+
+//coversSegment checks if a held segment matches everything a single pattern segment matches
+func coversSegment(held string, pattern string) bool {
+	if held == pattern || held == "*" {
+		return true
+	}
+	if strings.ContainsAny(pattern, `*?[]{}\`) {
+		return false
+	}
+	seg, err := compileSegment(held)
+	return err == nil && seg.match(pattern)
+}
+
+//compileLenient compiles List into GlobList skipping all invalid patterns
+func (g GlobComparator) compileLenient(p List) GlobList {
+	ps := make([]globPattern, 0, len(p.Permission))
+	for _, n := range p.Permission {
+		if gp, err := g.cachedPattern(n); err == nil {
+			ps = append(ps, gp)
+		}
+	}
+	return GlobList{list: p, comparator: g, patterns: ps}
+}
+
+//cachedPattern normalizes and parses a pattern, using Cache if it's set
+func (g GlobComparator) cachedPattern(pattern string) (globPattern, error) {
+	key := globKey{deliminator: g.Deliminator, pattern: g.Normalizer.normalize(pattern)}
+	if g.Cache == nil {
+		return g.compilePattern(key.pattern)
+	}
+	if gp, ok := g.Cache.load(key); ok {
+		return gp, nil
+	}
+	gp, err := g.compilePattern(key.pattern)
+	if err != nil {
+		return nil, err
+	}
+	g.Cache.store(key, gp)
+	return gp, nil
+}
+
+//compilePattern splits a pattern into segments and compiles every segment
+func (g GlobComparator) compilePattern(pattern string) (globPattern, error) {
+	parts := g.split(pattern)
+	segs := make([]globSegment, 0, len(parts))
+	for _, part := range parts {
+		seg, err := compileSegment(part)
+		if err != nil {
+			return nil, err
+		}
+		//consecutive ** are the same as a single one
+		if seg.recursive && len(segs) > 0 && segs[len(segs)-1].recursive {
+			continue
+		}
+		segs = append(segs, seg)
+	}
+	return segs, nil
+}
+
+//Insure GlobList is SelfComparator
+var _ SelfComparator = (*GlobList)(nil)
+
+//GlobList is a List with all its permission precompiled by GlobComparator.Compile
+type GlobList struct {
+	list       List
+	comparator GlobComparator
+	patterns   []globPattern
+}
+
+//List returns the List GlobList was compiled from
+func (l GlobList) List() List {
+	return l.list
+}
+
+//HasPermission checks if any compiled pattern matches the node
+func (l GlobList) HasPermission(node string) bool {
+	node = l.comparator.Normalizer.normalize(node)
+	var ns []string
+	if l.comparator.Deliminator == "" {
+		ns = []string{node}
+	} else {
+		ns = strings.Split(node, l.comparator.Deliminator)
+	}
+	for _, p := range l.patterns {
+		if p.match(ns) {
+			return true
+		}
+	}
+	return false
+}
+
+//HasPermissionWithLevel checks if any compiled pattern matches the node and the level requirement is met
+//the most specific scoped level whose key is the node or one of its parents is used over List.Level
+func (l GlobList) HasPermissionWithLevel(node string, level int) bool {
+	if !l.comparator.LevelPolicy.meets(levelFor(l.list, l.comparator.Normalizer.normalize(node), l.comparator.Deliminator), level) {
+		return false
+	}
+	return l.HasPermission(node)
+}
+
+func (l GlobList) IsHigherLevel(subject List) bool {
+	return l.comparator.IsHigherLevel(l.list, subject)
+}
+
+//globPattern is a compiled pattern, made out of segments
+type globPattern []globSegment
+
+//match checks if the pattern matches all the node segments
+func (p globPattern) match(ns []string) bool {
+	for len(p) > 0 {
+		if p[0].recursive {
+			p = p[1:]
+			if len(p) == 0 {
+				return true
+			}
+			for i := 0; i <= len(ns); i++ {
+				if p.match(ns[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+		if len(ns) == 0 || !p[0].match(ns[0]) {
+			return false
+		}
+		p, ns = p[1:], ns[1:]
+	}
+	return len(ns) == 0
+}
+
+//globSegment is a single compiled segment of a pattern
+//it's either recursive(**), a plain literal, or a regexp
+type globSegment struct {
+	recursive bool
+	literal   string
+	re        *regexp.Regexp
+}
+
+func (s globSegment) match(n string) bool {
+	if s.re != nil {
+		return s.re.MatchString(n)
+	}
+	return s.literal == n
+}
+
+//compileSegment translates a single glob segment into a globSegment
+func compileSegment(s string) (globSegment, error) {
+	if s == "**" {
+		return globSegment{recursive: true}, nil
+	}
+	if !strings.ContainsAny(s, `*?[]{}\`) {
+		return globSegment{literal: s}, nil
+	}
+	var b strings.Builder
+	b.WriteString("^")
+	alt := 0
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; c {
+		case '*':
+			b.WriteString(".*")
+		case '?':
+			b.WriteString(".")
+		case '{':
+			alt++
+			b.WriteString("(?:")
+		case ',':
+			if alt > 0 {
+				b.WriteString("|")
+			} else {
+				b.WriteString(",")
+			}
+		case '}':
+			if alt == 0 {
+				return globSegment{}, errors.New("unexpected }")
+			}
+			alt--
+			b.WriteString(")")
+		case '\\':
+			if i+1 >= len(s) {
+				return globSegment{}, errors.New("trailing escape")
+			}
+			i++
+			b.WriteString(regexp.QuoteMeta(s[i : i+1]))
+		case '[':
+			end, err := translateClass(&b, s[i:])
+			if err != nil {
+				return globSegment{}, err
+			}
+			i += end
+		default:
+			b.WriteString(regexp.QuoteMeta(s[i : i+1]))
+		}
+	}
+	if alt > 0 {
+		return globSegment{}, errors.New("unterminated {")
+	}
+	b.WriteString("$")
+	re, err := regexp.Compile(b.String())
+	if err != nil {
+		return globSegment{}, err
+	}
+	return globSegment{re: re}, nil
+}
+
+//translateClass translates a character class that s starts with into regexp syntax
+//returns the index of the closing ]
+func translateClass(b *strings.Builder, s string) (int, error) {
+	b.WriteString("[")
+	i := 1
+	if i < len(s) && (s[i] == '!' || s[i] == '^') {
+		b.WriteString("^")
+		i++
+	}
+	if i < len(s) && s[i] == ']' {
+		b.WriteString(`\]`)
+		i++
+	}
+	for ; i < len(s); i++ {
+		switch c := s[i]; c {
+		case ']':
+			b.WriteString("]")
+			return i, nil
+		case '\\':
+			if i+1 >= len(s) {
+				return 0, errors.New("trailing escape")
+			}
+			i++
+			if c := s[i]; c < 0x80 && !isAlphaNumeric(c) {
+				b.WriteByte('\\')
+			}
+			b.WriteByte(s[i])
+		case '[':
+			b.WriteString(`\[`)
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return 0, errors.New("unterminated character class")
+}
+
+func isAlphaNumeric(c byte) bool {
+	return 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || '0' <= c && c <= '9'
+}
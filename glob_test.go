@@ -0,0 +1,185 @@
+package roller
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestGlobComparator_HasPermission(t *testing.T) {
+	tests := []struct {
+		name       string
+		permission []string
+		node       string
+		want       bool
+		invalid    bool
+	}{
+		{name: "Empty", permission: nil, node: "foo", want: false},
+		{name: "Literal", permission: []string{"foo.bar"}, node: "foo.bar", want: true},
+		{name: "Literal mismatch", permission: []string{"foo.bar"}, node: "foo.bar.baz", want: false},
+		{name: "Star segment", permission: []string{"foo.*"}, node: "foo.bar", want: true},
+		{name: "Star one segment only", permission: []string{"foo.*"}, node: "foo.bar.baz", want: false},
+		{name: "Star needs segment", permission: []string{"foo.*"}, node: "foo", want: false},
+		{name: "Partial star", permission: []string{"reports.2024-*.read"}, node: "reports.2024-05.read", want: true},
+		{name: "Partial star mismatch", permission: []string{"reports.2024-*.read"}, node: "reports.2023-05.read", want: false},
+		{name: "Double star", permission: []string{"tenant.**"}, node: "tenant.a.b.c", want: true},
+		{name: "Double star zero segments", permission: []string{"tenant.**"}, node: "tenant", want: true},
+		{name: "Double star middle", permission: []string{"a.**.z"}, node: "a.b.c.z", want: true},
+		{name: "Double star middle adjacent", permission: []string{"a.**.z"}, node: "a.z", want: true},
+		{name: "Double star middle mismatch", permission: []string{"a.**.z"}, node: "a.b.c", want: false},
+		{name: "Repeated double star", permission: []string{"a.**.**.z"}, node: "a.b.z", want: true},
+		{name: "Question", permission: []string{"foo.ba?"}, node: "foo.baz", want: true},
+		{name: "Question length", permission: []string{"foo.ba?"}, node: "foo.ba", want: false},
+		{name: "Class", permission: []string{"tier.[0-9]"}, node: "tier.3", want: true},
+		{name: "Class mismatch", permission: []string{"tier.[0-9]"}, node: "tier.x", want: false},
+		{name: "Negated class", permission: []string{"tier.[!0-9]"}, node: "tier.x", want: true},
+		{name: "Caret negated class", permission: []string{"tier.[^0-9]"}, node: "tier.3", want: false},
+		{name: "Alternation", permission: []string{"tenant.{a,b}.*"}, node: "tenant.b.read", want: true},
+		{name: "Alternation mismatch", permission: []string{"tenant.{a,b}.*"}, node: "tenant.c.read", want: false},
+		{name: "Escape", permission: []string{`foo.\*`}, node: "foo.*", want: true},
+		{name: "Escape literal", permission: []string{`foo.\*`}, node: "foo.bar", want: false},
+		{name: "Regexp meta is literal", permission: []string{"foo.(bar)+*"}, node: "foo.(bar)+x", want: true},
+		{name: "Invalid ignored", permission: []string{"foo.[", "foo.*"}, node: "foo.bar", want: true, invalid: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := GlobComparator{Deliminator: "."}
+			a := assert.New(t)
+			a.Equal(tt.want, g.HasPermission(List{Permission: tt.permission}, tt.node))
+
+			if tt.invalid {
+				return
+			}
+			gl, err := g.Compile(List{Permission: tt.permission})
+			a.NoError(err)
+			a.Equal(tt.want, gl.HasPermission(tt.node))
+		})
+	}
+}
+
+func TestGlobComparator_Compile(t *testing.T) {
+	tests := []struct {
+		name    string
+		pattern string
+		wantErr bool
+	}{
+		{name: "Literal", pattern: "foo.bar"},
+		{name: "Glob", pattern: "foo.*.b?r.[a-z].{x,y}.**"},
+		{name: "Bracket in class", pattern: "foo.[]a]"},
+		{name: "Unterminated class", pattern: "foo.[a-z", wantErr: true},
+		{name: "Bad range", pattern: "foo.[z-a]", wantErr: true},
+		{name: "Unterminated alternation", pattern: "foo.{a,b", wantErr: true},
+		{name: "Alternation across deliminator", pattern: "foo.{a.b,c}", wantErr: true},
+		{name: "Unexpected close", pattern: "foo.a}", wantErr: true},
+		{name: "Trailing escape", pattern: `foo.a\`, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := require.New(t)
+			g := GlobComparator{Deliminator: "."}
+			_, err := g.Compile(List{Permission: []string{"ok", tt.pattern}})
+			if !tt.wantErr {
+				r.NoError(err)
+				return
+			}
+			r.Error(err)
+			var e InvalidPatternError
+			r.ErrorAs(err, &e)
+			r.Equal(tt.pattern, e.Pattern())
+			r.NotNil(e.Unwrap())
+		})
+	}
+}
+
+func TestGlobList_Level(t *testing.T) {
+	a := assert.New(t)
+	l := List{
+		Level:      2,
+		Levels:     map[string]int{"chat": 10},
+		Permission: []string{"chat.**", "world.*"},
+	}
+	g := GlobComparator{Deliminator: ".", LevelPolicy: LevelGreaterOrEqual}
+	gl, err := g.Compile(l)
+	a.NoError(err)
+	a.Equal(l, gl.List())
+	a.True(gl.HasPermissionWithLevel("chat.send", 10))
+	a.False(gl.HasPermissionWithLevel("chat.send", 11))
+	a.True(gl.HasPermissionWithLevel("world.build", 2))
+	a.False(gl.HasPermissionWithLevel("world.build", 3))
+	a.True(gl.IsHigherLevel(List{Level: 2}))
+	a.False(gl.IsHigherLevel(List{Level: 3}))
+	a.Equal(gl.HasPermissionWithLevel("chat.send", 10), g.HasPermissionWithLevel(l, "chat.send", 10))
+}
+
+func TestGlobComparator_NoDeliminator(t *testing.T) {
+	a := assert.New(t)
+	g := GlobComparator{}
+	l := List{Permission: []string{"foo*"}}
+	a.True(g.HasPermission(l, "foo.bar.baz"))
+	a.False(g.HasPermission(l, "bar.foo"))
+}
+
+func TestGlobComparator_Normalizer(t *testing.T) {
+	a := assert.New(t)
+	g := GlobComparator{Deliminator: ".", Normalizer: NormalizeLower}
+	l := List{Levels: map[string]int{"chat": 5}, Permission: []string{"Chat.*"}}
+	a.True(g.HasPermission(l, "CHAT.Send"))
+	a.True(g.HasPermissionWithLevel(l, "Chat.send", 4))
+	a.False(GlobComparator{Deliminator: "."}.HasPermission(l, "chat.send"))
+}
+
+func TestGlobComparator_Cache(t *testing.T) {
+	a := assert.New(t)
+	g := GlobComparator{Deliminator: ".", Cache: NewGlobCache(2)}
+	l := List{Permission: []string{"cache.[a-", "cache.t?st"}}
+	a.True(g.HasPermission(l, "cache.test"))
+	a.Equal(1, g.Cache.Len(), "invalid patterns should not be cached")
+	a.True(g.HasPermission(l, "cache.test"))
+	a.Equal(1, g.Cache.Len())
+
+	a.True(g.HasPermission(List{Permission: []string{"a.*", "b.*", "c.*"}}, "c.x"))
+	a.Equal(2, g.Cache.Len(), "cache should be bounded")
+	_, ok := g.Cache.load(globKey{deliminator: ".", pattern: "cache.t?st"})
+	a.False(ok, "least recently used pattern should be evicted")
+
+	_, err := g.Compile(l)
+	var e InvalidPatternError
+	a.ErrorAs(err, &e)
+	a.Equal("cache.[a-", e.Pattern())
+}
+
+func BenchmarkGlobList_HasPermission(b *testing.B) {
+	g := GlobComparator{Deliminator: "."}
+	perms := randSlice(100, 10)
+	for i := range perms {
+		perms[i] += ".*.r?ad"
+	}
+	gl, err := g.Compile(List{Permission: perms})
+	if err != nil {
+		b.Fatal(err)
+	}
+	node := perms[len(perms)-1][:10] + ".x.read"
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		gl.HasPermission(node)
+	}
+}
+
+func TestGlobComparator_Covers(t *testing.T) {
+	as := assert.New(t)
+	g := GlobComparator{Deliminator: "."}
+	l := List{Permission: []string{"admin.*", "chat.**", "world.{build,break}", "[invalid"}}
+	as.True(g.Covers(l, "admin.*"))
+	as.True(g.Covers(l, "admin.kick"))
+	as.True(g.Covers(l, "admin.k?ck"))
+	as.False(g.Covers(l, "admin.**"))
+	as.False(g.Covers(l, "admin.kick.all"))
+	as.True(g.Covers(l, "chat.**"))
+	as.True(g.Covers(l, "chat.*.send"))
+	as.True(g.Covers(l, "chat"))
+	as.True(g.Covers(l, "world.build"))
+	as.False(g.Covers(l, "world.*"))
+	as.False(g.Covers(l, "*"))
+	as.True(g.Covers(l, "[invalid"), "a pattern held as is is covered")
+	as.False(g.Covers(l, "[invalid.x"))
+}
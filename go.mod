@@ -2,4 +2,8 @@ module github.com/Thunder33345/roller
 
 go 1.16
 
-require github.com/stretchr/testify v1.7.0
+require (
+	github.com/stretchr/testify v1.7.0
+	go.etcd.io/bbolt v1.3.6
+	golang.org/x/text v0.3.7
+)
@@ -0,0 +1,187 @@
+package roller
+
+import "sort"
+
+//Ladder is an ordered list of groups a RawList can be promoted or demoted through, such as a staff rank track
+//a RawList is expected to only have one group of the ladder, if it has more the highest one is used
+type Ladder struct {
+	//Groups are the group IDs of the ladder, ordered from the lowest to the highest
+	Groups []string
+	//Processor is used by PromoteBy and DemoteBy to process the target RawList
+	Processor Processor
+	//Comparator is used by PromoteBy and DemoteBy to compare the actor and the target
+	Comparator Comparator
+}
+
+//GroupWalker is a GroupProvider that can iterate through all its groups, such as provider.Walker
+type GroupWalker interface {
+	//WalkGroup calls f with every group, until f returns halt as true
+	WalkGroup(func(group Group, last bool) (halt bool)) error
+}
+
+//LoadLadder loads groups from provider and creates a Ladder ordered by Group.Weight
+//the group with the smallest weight will be the lowest, unless weightAscending is true
+//returns MissingGroupError if any group can't be loaded
+func LoadLadder(provider GroupProvider, ids []string, weightAscending bool) (Ladder, error) {
+	gs := make([]Group, 0, len(ids))
+	for _, id := range ids {
+		g, err := provider.Group(id)
+		if err != nil {
+			return Ladder{}, NewMissingGroupsError(id, err)
+		}
+		gs = append(gs, g)
+	}
+	return newLadder(gs, weightAscending), nil
+}
+
+//LoadTrackLadder walks every group of walker and creates a Ladder out of the groups with Group.Track of track
+//the ladder is ordered the same as LoadLadder
+//returns UnknownTrackError if no group is on the track
+func LoadTrackLadder(walker GroupWalker, track string, weightAscending bool) (Ladder, error) {
+	var gs []Group
+	if err := walker.WalkGroup(func(group Group, last bool) bool {
+		if group.Track == track {
+			gs = append(gs, group)
+		}
+		return false
+	}); err != nil {
+		return Ladder{}, err
+	}
+	if len(gs) == 0 {
+		return Ladder{}, NewUnknownTrackError(track)
+	}
+	return newLadder(gs, weightAscending), nil
+}
+
+//newLadder creates a Ladder out of gs ordered by Group.Weight, gs is sorted in place
+func newLadder(gs []Group, weightAscending bool) Ladder {
+	sort.SliceStable(gs, func(i, j int) bool {
+		if weightAscending {
+			return gs[i].Weight > gs[j].Weight
+		}
+		return gs[i].Weight < gs[j].Weight
+	})
+	l := Ladder{Groups: make([]string, 0, len(gs))}
+	for _, g := range gs {
+		l.Groups = append(l.Groups, g.ID)
+	}
+	return l
+}
+
+//Promote returns a copy of RawList with its current ladder group swapped for the next higher one
+//a RawList that isn't on the ladder yet will be given the lowest group
+//any other ladder group the RawList has is removed
+//returns LadderEndError if the RawList is already at the top
+func (l Ladder) Promote(r RawList) (RawList, error) {
+	return l.move(r, 1)
+}
+
+//Demote returns a copy of RawList with its current ladder group swapped for the next lower one
+//any other ladder group the RawList has is removed
+//returns LadderEndError if the RawList is already at the bottom or isn't on the ladder
+func (l Ladder) Demote(r RawList) (RawList, error) {
+	return l.move(r, -1)
+}
+
+//PromoteBy is Promote, but also checks if the actor is allowed to do so
+//returns InsufficientLevelError if the actor isn't higher level than the target before or after the promotion
+//returns IncompleteLadderError if Ladder.Processor or Ladder.Comparator isn't set
+func (l Ladder) PromoteBy(actor List, r RawList) (RawList, error) {
+	o, err := l.Promote(r)
+	if err != nil {
+		return RawList{}, err
+	}
+	if err := l.checkActor(actor, r, o); err != nil {
+		return RawList{}, err
+	}
+	return o, nil
+}
+
+//DemoteBy is Demote, but also checks if the actor is allowed to do so
+//returns InsufficientLevelError if the actor isn't higher level than the target before or after the demotion
+//returns IncompleteLadderError if Ladder.Processor or Ladder.Comparator isn't set
+func (l Ladder) DemoteBy(actor List, r RawList) (RawList, error) {
+	o, err := l.Demote(r)
+	if err != nil {
+		return RawList{}, err
+	}
+	if err := l.checkActor(actor, r, o); err != nil {
+		return RawList{}, err
+	}
+	return o, nil
+}
+
+//Current returns the highest ladder group RawList has
+//returns -1 and "" if RawList isn't on the ladder
+func (l Ladder) Current(r RawList) (int, string) {
+	cur := -1
+	for _, g := range r.Groups {
+		if i := l.index(g); i > cur {
+			cur = i
+		}
+	}
+	if cur < 0 {
+		return -1, ""
+	}
+	return cur, l.Groups[cur]
+}
+
+//move swaps the current ladder group of r with the one offset away
+func (l Ladder) move(r RawList, offset int) (RawList, error) {
+	cur, id := l.Current(r)
+	next := cur + offset
+	if cur < 0 && offset > 0 {
+		next = 0
+	}
+	if next < 0 || next >= len(l.Groups) {
+		return RawList{}, NewLadderEndError(id, offset > 0)
+	}
+
+	o := r
+	o.Groups = make([]string, 0, len(r.Groups)+1)
+	placed := false
+	for _, g := range r.Groups {
+		if l.index(g) < 0 {
+			o.Groups = append(o.Groups, g)
+			continue
+		}
+		if !placed && g == id {
+			o.Groups = append(o.Groups, l.Groups[next])
+			placed = true
+		}
+	}
+	if !placed {
+		o.Groups = append(o.Groups, l.Groups[next])
+	}
+	return o, nil
+}
+
+//checkActor checks if actor is higher level than the processed before and after RawList
+func (l Ladder) checkActor(actor List, before RawList, after RawList) error {
+	if l.Processor == nil {
+		return NewIncompleteLadderError("Processor")
+	}
+	if l.Comparator == nil {
+		return NewIncompleteLadderError("Comparator")
+	}
+	for _, r := range []RawList{before, after} {
+		t, err := l.Processor.Process(r)
+		if err != nil {
+			return err
+		}
+		if !l.Comparator.IsHigherLevel(actor, t) {
+			return NewInsufficientLevelError(actor.Level, t.Level)
+		}
+	}
+	return nil
+}
+
+//index returns the position of group in the ladder, or -1
+func (l Ladder) index(group string) int {
+	for i, g := range l.Groups {
+		if g == group {
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,184 @@
+package roller
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func ladderGroups() []Group {
+	return []Group{
+		{ID: "admin", Weight: 30, Permission: Entry{Level: 30}},
+		{ID: "member", Weight: 10, Permission: Entry{Level: 10}},
+		{ID: "mod", Weight: 20, Permission: Entry{Level: 20}},
+		{ID: "vip", Weight: 5, Permission: Entry{Grant: []string{"vip"}}},
+	}
+}
+
+func TestLoadLadder(t *testing.T) {
+	r := require.New(t)
+	p := &dummyProvider{groups: ladderGroups()}
+	l, err := LoadLadder(p, []string{"admin", "member", "mod"}, false)
+	r.NoError(err)
+	r.Equal([]string{"member", "mod", "admin"}, l.Groups)
+
+	l, err = LoadLadder(p, []string{"admin", "member", "mod"}, true)
+	r.NoError(err)
+	r.Equal([]string{"admin", "mod", "member"}, l.Groups)
+
+	_, err = LoadLadder(p, []string{"admin", "owner"}, false)
+	var e MissingGroupError
+	r.ErrorAs(err, &e)
+	r.Equal("owner", e.Group())
+}
+
+//dummyWalker walks groups in order
+type dummyWalker []Group
+
+func (d dummyWalker) WalkGroup(f func(group Group, last bool) (halt bool)) error {
+	for i, g := range d {
+		if f(g, len(d)-1 == i) {
+			return nil
+		}
+	}
+	return nil
+}
+
+func TestLoadTrackLadder(t *testing.T) {
+	r := require.New(t)
+	gs := ladderGroups()
+	for i := range gs {
+		if gs[i].ID != "vip" {
+			gs[i].Track = "staff"
+		}
+	}
+	l, err := LoadTrackLadder(dummyWalker(gs), "staff", false)
+	r.NoError(err)
+	r.Equal([]string{"member", "mod", "admin"}, l.Groups)
+	l, err = LoadTrackLadder(dummyWalker(gs), "staff", true)
+	r.NoError(err)
+	r.Equal([]string{"admin", "mod", "member"}, l.Groups)
+
+	_, err = LoadTrackLadder(dummyWalker(gs), "tier", false)
+	var e UnknownTrackError
+	r.ErrorAs(err, &e)
+	r.Equal("tier", e.Track())
+
+	_, err = l.PromoteBy(List{Level: 100}, RawList{Groups: []string{"mod"}})
+	var ie IncompleteLadderError
+	r.ErrorAs(err, &ie)
+	r.Equal("Processor", ie.Field())
+	l.Processor = BasicProcessor{Provider: &dummyProvider{groups: gs}}
+	_, err = l.DemoteBy(List{Level: 100}, RawList{Groups: []string{"mod"}})
+	r.ErrorAs(err, &ie)
+	r.Equal("ladder has no Comparator set", ie.Error())
+}
+
+func TestLadder_Move(t *testing.T) {
+	l := Ladder{Groups: []string{"member", "mod", "admin"}}
+	tests := []struct {
+		name        string
+		groups      []string
+		promote     []string
+		promoteErr  bool
+		demote      []string
+		demoteErr   bool
+		wantCurrent string
+	}{
+		{
+			name:        "Not on ladder",
+			groups:      []string{"vip"},
+			promote:     []string{"vip", "member"},
+			demoteErr:   true,
+			wantCurrent: "",
+		}, {
+			name:        "Bottom",
+			groups:      []string{"member", "vip"},
+			promote:     []string{"mod", "vip"},
+			demoteErr:   true,
+			wantCurrent: "member",
+		}, {
+			name:        "Middle",
+			groups:      []string{"vip", "mod"},
+			promote:     []string{"vip", "admin"},
+			demote:      []string{"vip", "member"},
+			wantCurrent: "mod",
+		}, {
+			name:        "Top",
+			groups:      []string{"admin"},
+			promoteErr:  true,
+			demote:      []string{"mod"},
+			wantCurrent: "admin",
+		}, {
+			name:        "Multiple rungs",
+			groups:      []string{"member", "vip", "mod"},
+			promote:     []string{"vip", "admin"},
+			demote:      []string{"vip", "member"},
+			wantCurrent: "mod",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := assert.New(t)
+			r := RawList{Groups: append([]string(nil), tt.groups...), Overwrites: Entry{Level: 1}}
+			_, cur := l.Current(r)
+			a.Equal(tt.wantCurrent, cur)
+
+			got, err := l.Promote(r)
+			if tt.promoteErr {
+				var e LadderEndError
+				a.ErrorAs(err, &e)
+				a.True(e.Top())
+				a.Equal(tt.wantCurrent, e.Group())
+			} else {
+				a.NoError(err)
+				a.Equal(tt.promote, got.Groups)
+				a.Equal(r.Overwrites, got.Overwrites)
+			}
+
+			got, err = l.Demote(r)
+			if tt.demoteErr {
+				var e LadderEndError
+				a.ErrorAs(err, &e)
+				a.False(e.Top())
+				a.Equal(tt.wantCurrent, e.Group())
+			} else {
+				a.NoError(err)
+				a.Equal(tt.demote, got.Groups)
+			}
+			a.Equal(tt.groups, r.Groups, "RawList should not be altered")
+		})
+	}
+}
+
+func TestLadder_MoveBy(t *testing.T) {
+	l := Ladder{
+		Groups:     []string{"member", "mod", "admin"},
+		Processor:  BasicProcessor{Provider: &dummyProvider{groups: ladderGroups()}},
+		Comparator: ExplicitComparator{},
+	}
+	a := assert.New(t)
+
+	got, err := l.PromoteBy(List{Level: 25}, RawList{Groups: []string{"member"}})
+	a.NoError(err)
+	a.Equal([]string{"mod"}, got.Groups)
+
+	_, err = l.PromoteBy(List{Level: 25}, RawList{Groups: []string{"mod"}})
+	var e InsufficientLevelError
+	a.ErrorAs(err, &e)
+	a.Equal(25, e.Actor())
+	a.Equal(30, e.Target())
+	a.Equal("actor level 25 is not higher than target level 30", e.Error())
+
+	got, err = l.DemoteBy(List{Level: 25}, RawList{Groups: []string{"mod"}})
+	a.NoError(err)
+	a.Equal([]string{"member"}, got.Groups)
+
+	_, err = l.DemoteBy(List{Level: 25}, RawList{Groups: []string{"admin"}})
+	a.ErrorAs(err, &e)
+	a.Equal(30, e.Target())
+
+	_, err = l.PromoteBy(List{Level: 100}, RawList{Groups: []string{"member", "missing"}})
+	var me MissingGroupError
+	a.ErrorAs(err, &me)
+}
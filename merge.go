@@ -0,0 +1,120 @@
+package roller
+
+//MergeStrategy decides how BasicProcessor merges each Entry into the List being built
+//every Entry applied will go through MergeLevel once, and MergeNodes once
+type MergeStrategy interface {
+	//MergeLevel returns the new level after merging an Entry level into current
+	//it's used for both List.Level and every key of List.Levels
+	//exists is false if no Entry has specified this level before, set is the Entry.SetLevel
+	//an Entry specifies a level when its level is not 0 or set is true
+	MergeLevel(current int, exists bool, level int, set bool) int
+	//MergeNodes applies the grants and revokes of an Entry through NodeApplier
+	MergeNodes(a NodeApplier, set Entry)
+}
+
+//NodeApplier is used by MergeStrategy to alter the nodes of the List being built
+//it follows the BasicProcessor rules for normalizing, deduplicating and wildcard revoking
+type NodeApplier interface {
+	//Nodes returns the currently granted nodes, it must not be altered
+	Nodes() []string
+	//Clear revokes every granted node and exclusion
+	Clear()
+	//Revoke revokes nodes
+	Revoke(nodes []string)
+	//Grant grants nodes that are not granted yet
+	Grant(nodes []string)
+}
+
+//NodeMerge applies Entry nodes the way BasicProcessor always has:
+//Entry.EmptySet clears all nodes, otherwise Entry.Revoke is revoked, then Entry.Grant is granted
+//it's meant to be embedded by MergeStrategy that only change how levels are merged
+type NodeMerge struct{}
+
+func (NodeMerge) MergeNodes(a NodeApplier, set Entry) {
+	if set.EmptySet {
+		a.Clear()
+	} else if len(set.Revoke) > 0 {
+		a.Revoke(set.Revoke)
+	}
+	if len(set.Grant) > 0 {
+		a.Grant(set.Grant)
+	}
+}
+
+var _ MergeStrategy = (*AdditiveMerge)(nil)
+
+//AdditiveMerge adds levels together, unless SetLevel is true which overwrites it
+//this is the default MergeStrategy
+type AdditiveMerge struct {
+	NodeMerge
+}
+
+func (AdditiveMerge) MergeLevel(current int, _ bool, level int, set bool) int {
+	if set {
+		return level
+	}
+	return current + level
+}
+
+var _ MergeStrategy = (*SetMerge)(nil)
+
+//SetMerge treats every Entry as SetLevel, the last Entry applied always decides the level
+//even if it didn't specify a level, in which case the level would be 0
+type SetMerge struct {
+	NodeMerge
+}
+
+func (SetMerge) MergeLevel(_ int, _ bool, level int, _ bool) int {
+	return level
+}
+
+var _ MergeStrategy = (*LastWinsMerge)(nil)
+
+//LastWinsMerge uses the level of the last Entry applied that specified a level
+//so the highest weighted group with a level decides the level
+type LastWinsMerge struct {
+	NodeMerge
+}
+
+func (LastWinsMerge) MergeLevel(current int, _ bool, level int, set bool) int {
+	if level == 0 && !set {
+		return current
+	}
+	return level
+}
+
+var _ MergeStrategy = (*MaxMerge)(nil)
+
+//MaxMerge uses the highest level specified by any Entry
+//SetLevel still overwrites the level, allowing a higher weighted Entry to lower it
+type MaxMerge struct {
+	NodeMerge
+}
+
+func (MaxMerge) MergeLevel(current int, exists bool, level int, set bool) int {
+	if set {
+		return level
+	}
+	if level == 0 || (exists && current >= level) {
+		return current
+	}
+	return level
+}
+
+var _ MergeStrategy = (*MinMerge)(nil)
+
+//MinMerge uses the lowest level specified by any Entry
+//SetLevel still overwrites the level, allowing a higher weighted Entry to raise it
+type MinMerge struct {
+	NodeMerge
+}
+
+func (MinMerge) MergeLevel(current int, exists bool, level int, set bool) int {
+	if set {
+		return level
+	}
+	if level == 0 || (exists && current <= level) {
+		return current
+	}
+	return level
+}
@@ -0,0 +1,137 @@
+package roller
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestMergeStrategy_MergeLevel(t *testing.T) {
+	type args struct {
+		current int
+		exists  bool
+		level   int
+		set     bool
+	}
+	tests := []struct {
+		name     string
+		strategy MergeStrategy
+		args     args
+		want     int
+	}{
+		{name: "Additive add", strategy: AdditiveMerge{}, args: args{current: 5, exists: true, level: 3}, want: 8},
+		{name: "Additive set", strategy: AdditiveMerge{}, args: args{current: 5, exists: true, level: 3, set: true}, want: 3},
+		{name: "Set", strategy: SetMerge{}, args: args{current: 5, exists: true, level: 3}, want: 3},
+		{name: "Set unspecified", strategy: SetMerge{}, args: args{current: 5, exists: true}, want: 0},
+		{name: "Last wins", strategy: LastWinsMerge{}, args: args{current: 5, exists: true, level: 3}, want: 3},
+		{name: "Last wins unspecified", strategy: LastWinsMerge{}, args: args{current: 5, exists: true}, want: 5},
+		{name: "Last wins set zero", strategy: LastWinsMerge{}, args: args{current: 5, exists: true, set: true}, want: 0},
+		{name: "Max higher", strategy: MaxMerge{}, args: args{current: 5, exists: true, level: 7}, want: 7},
+		{name: "Max lower", strategy: MaxMerge{}, args: args{current: 5, exists: true, level: 3}, want: 5},
+		{name: "Max first negative", strategy: MaxMerge{}, args: args{level: -3}, want: -3},
+		{name: "Max unspecified", strategy: MaxMerge{}, args: args{current: -5, exists: true}, want: -5},
+		{name: "Max set", strategy: MaxMerge{}, args: args{current: 5, exists: true, level: 3, set: true}, want: 3},
+		{name: "Min lower", strategy: MinMerge{}, args: args{current: 5, exists: true, level: 3}, want: 3},
+		{name: "Min higher", strategy: MinMerge{}, args: args{current: 5, exists: true, level: 7}, want: 5},
+		{name: "Min first", strategy: MinMerge{}, args: args{level: 3}, want: 3},
+		{name: "Min unspecified", strategy: MinMerge{}, args: args{current: 5, exists: true}, want: 5},
+		{name: "Min set", strategy: MinMerge{}, args: args{current: 5, exists: true, level: 7, set: true}, want: 7},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := assert.New(t)
+			a.Equal(tt.want, tt.strategy.MergeLevel(tt.args.current, tt.args.exists, tt.args.level, tt.args.set))
+		})
+	}
+}
+
+func TestBasicProcessor_MergeStrategy(t *testing.T) {
+	//wantLevels maps every strategy to the expected level of each getProcessTests scenario
+	//permissions are expected to be unaffected by all built in strategies
+	tests := []struct {
+		name       string
+		strategy   MergeStrategy
+		wantLevels map[string]int
+	}{
+		{
+			name:       "Default",
+			strategy:   nil,
+			wantLevels: map[string]int{"Simple 1": 18, "Simple 2": 13, "3rd test": 46, "4th Reverse": 6},
+		}, {
+			name:       "Additive",
+			strategy:   AdditiveMerge{},
+			wantLevels: map[string]int{"Simple 1": 18, "Simple 2": 13, "3rd test": 46, "4th Reverse": 6},
+		}, {
+			name:       "Set",
+			strategy:   SetMerge{},
+			wantLevels: map[string]int{"Simple 1": 3, "Simple 2": 10, "3rd test": 50, "4th Reverse": -1},
+		}, {
+			name:       "Last wins",
+			strategy:   LastWinsMerge{},
+			wantLevels: map[string]int{"Simple 1": 3, "Simple 2": 10, "3rd test": 50, "4th Reverse": -1},
+		}, {
+			name:       "Max",
+			strategy:   MaxMerge{},
+			wantLevels: map[string]int{"Simple 1": 10, "Simple 2": 10, "3rd test": 50, "4th Reverse": 5},
+		}, {
+			name:       "Min",
+			strategy:   MinMerge{},
+			wantLevels: map[string]int{"Simple 1": 3, "Simple 2": 1, "3rd test": -5, "4th Reverse": -1},
+		},
+	}
+	for _, st := range tests {
+		for _, tt := range getProcessTests() {
+			t.Run(st.name+"/"+tt.name, func(t *testing.T) {
+				a := assert.New(t)
+				want, ok := st.wantLevels[tt.name]
+				a.True(ok, "Missing expected level for scenario")
+				p := BasicProcessor{
+					Provider:        &dummyProvider{groups: tt.fields.Groups},
+					WeightAscending: tt.fields.WeightAscending,
+					Merge:           st.strategy,
+				}
+				got, err := p.Process(tt.r)
+				a.NoError(err)
+				a.Equal(want, got.Level, "Level should be equal")
+				a.Equal(tt.want.Permission, got.Permission, "Permissions should be equal")
+			})
+		}
+	}
+}
+
+//grantOnlyMerge is a MergeStrategy that ignores revokes
+type grantOnlyMerge struct {
+	AdditiveMerge
+}
+
+func (grantOnlyMerge) MergeNodes(a NodeApplier, set Entry) {
+	a.Grant(set.Grant)
+}
+
+func TestBasicProcessor_CustomMergeStrategy(t *testing.T) {
+	a := assert.New(t)
+	p := BasicProcessor{
+		Provider: &dummyProvider{groups: []Group{
+			{ID: "1", Weight: 1, Permission: Entry{Level: 1, Grant: []string{"foo", "bar"}}},
+			{ID: "2", Weight: 2, Permission: Entry{Level: 2, EmptySet: true, Revoke: []string{"foo"}, Grant: []string{"baz", "foo"}}},
+		}},
+		Merge: grantOnlyMerge{},
+	}
+	got, err := p.Process(RawList{Groups: []string{"1", "2"}})
+	a.NoError(err)
+	a.Equal(3, got.Level)
+	a.Equal([]string{"foo", "bar", "baz"}, got.Permission)
+}
+
+func TestBasicProcessor_MergeStrategyLevels(t *testing.T) {
+	a := assert.New(t)
+	p := BasicProcessor{
+		Provider: &dummyProvider{groups: []Group{
+			{ID: "1", Weight: 1, Permission: Entry{Levels: map[string]int{"chat": 10, "world": -2}}},
+			{ID: "2", Weight: 2, Permission: Entry{Levels: map[string]int{"chat": 3, "world": 4}}},
+		}},
+		Merge: MaxMerge{},
+	}
+	got, err := p.Process(RawList{Groups: []string{"1", "2"}})
+	a.NoError(err)
+	a.Equal(map[string]int{"chat": 10, "world": 4}, got.Levels)
+}
@@ -0,0 +1,75 @@
+package roller
+
+import (
+	"strings"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+//Normalizer transforms a permission node into its canonical form before it's stored or compared
+//it should be idempotent, normalizing an already normalized node must not change it
+//a nil Normalizer leaves nodes untouched, which is exact matching
+type Normalizer func(node string) string
+
+//NormalizeLower is a Normalizer that lower cases the node, so Chat.Send will match chat.send
+func NormalizeLower(node string) string {
+	return strings.ToLower(node)
+}
+
+//NormalizeTrim is a Normalizer that trims leading and trailing white spaces
+func NormalizeTrim(node string) string {
+	return strings.TrimSpace(node)
+}
+
+//NormalizeNFC is a Normalizer that converts the node into unicode normalization form C
+//so visually identical nodes typed with combining characters will match
+func NormalizeNFC(node string) string {
+	return norm.NFC.String(node)
+}
+
+//NormalizeDeliminator returns a Normalizer that collapses repeated deliminator into one
+//for example with . as deliminator chat..send becomes chat.send
+func NormalizeDeliminator(deliminator string) Normalizer {
+	if deliminator == "" {
+		return nil
+	}
+	double := deliminator + deliminator
+	return func(node string) string {
+		for strings.Contains(node, double) {
+			node = strings.ReplaceAll(node, double, deliminator)
+		}
+		return node
+	}
+}
+
+//ChainNormalizer returns a Normalizer that applies all given normalizer in order
+//nil normalizer are skipped
+func ChainNormalizer(normalizers ...Normalizer) Normalizer {
+	return func(node string) string {
+		for _, n := range normalizers {
+			node = n.normalize(node)
+		}
+		return node
+	}
+}
+
+//normalize returns the normalized node, or node itself if Normalizer is nil
+func (n Normalizer) normalize(node string) string {
+	if n == nil {
+		return node
+	}
+	return n(node)
+}
+
+//normalizeAll returns a normalized copy of nodes
+//nodes itself is returned if Normalizer is nil
+func (n Normalizer) normalizeAll(nodes []string) []string {
+	if n == nil || nodes == nil {
+		return nodes
+	}
+	o := make([]string, 0, len(nodes))
+	for _, v := range nodes {
+		o = append(o, n(v))
+	}
+	return o
+}
@@ -0,0 +1,70 @@
+package roller
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestNormalizer(t *testing.T) {
+	tests := []struct {
+		name       string
+		normalizer Normalizer
+		node       string
+		want       string
+	}{
+		{name: "Nil", normalizer: nil, node: " Chat..Send ", want: " Chat..Send "},
+		{name: "Lower", normalizer: NormalizeLower, node: "Chat.Send", want: "chat.send"},
+		{name: "Trim", normalizer: NormalizeTrim, node: " \tchat.send\n", want: "chat.send"},
+		{name: "NFC", normalizer: NormalizeNFC, node: "cafe\u0301.order", want: "caf\u00e9.order"},
+		{name: "Deliminator", normalizer: NormalizeDeliminator("."), node: "chat...send..all", want: "chat.send.all"},
+		{name: "Multi character deliminator", normalizer: NormalizeDeliminator("::"), node: "chat:::::send", want: "chat:::send"},
+		{name: "Empty deliminator", normalizer: NormalizeDeliminator(""), node: "chat..send", want: "chat..send"},
+		{
+			name:       "Chain",
+			normalizer: ChainNormalizer(NormalizeTrim, nil, NormalizeLower, NormalizeDeliminator(".")),
+			node:       " Chat..Send ",
+			want:       "chat.send",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := assert.New(t)
+			got := tt.normalizer.normalize(tt.node)
+			a.Equal(tt.want, got)
+			a.Equal(got, tt.normalizer.normalize(got), "Normalizer should be idempotent")
+		})
+	}
+}
+
+func TestNormalizer_normalizeAll(t *testing.T) {
+	a := assert.New(t)
+	in := []string{"Foo", "BAR"}
+	a.Equal([]string{"foo", "bar"}, Normalizer(NormalizeLower).normalizeAll(in))
+	a.Equal([]string{"Foo", "BAR"}, in)
+	a.Nil(Normalizer(NormalizeLower).normalizeAll(nil))
+	var n Normalizer
+	a.Equal(in, n.normalizeAll(in))
+}
+
+func TestComparator_Normalizer(t *testing.T) {
+	n := ChainNormalizer(NormalizeTrim, NormalizeLower, NormalizeDeliminator("."))
+	l := List{
+		Level:      5,
+		Levels:     map[string]int{"chat": 10},
+		Permission: []string{"Chat.Send", "world..Build*"},
+	}
+
+	e := ExplicitComparator{Normalizer: n}
+	a := assert.New(t)
+	a.True(e.HasPermission(l, "chat.send"))
+	a.True(e.HasPermission(l, " CHAT..send"))
+	a.False(ExplicitComparator{}.HasPermission(l, "chat.send"))
+	a.True(e.HasAll(l, "chat.send", "Chat.Send"))
+	a.Equal([]string{"CHAT.SEND"}, e.Filter(l, []string{"CHAT.SEND", "chat.kick"}))
+
+	i := ImplicitComparator{Deliminator: ".", Terminator: "*", Normalizer: n}
+	a.True(i.HasPermission(l, "World.build.house"))
+	a.False(ImplicitComparator{Deliminator: ".", Terminator: "*"}.HasPermission(l, "world.build.house"))
+	a.True(i.HasAny(l, "nope", "WORLD.BUILD"))
+	a.True(i.HasPermissionWithLevel(l, "Chat.Send", 9))
+}
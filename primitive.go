@@ -11,10 +11,16 @@ type Group struct {
 	//Weight dictates the overwriting precedent, where the larger overwrites the smaller
 	//must be unique, otherwise behaviour is undefined
 	Weight int `json:"weight"`
+	//Track is an optional category this group belongs to, such as staff rank or subscription tier
+	//when BasicProcessor.Tracks is set, only the highest precedent group of each track is applied
+	Track string `json:"track,omitempty"`
 	//Permission is the permission that is used
 	Permission Entry `json:"permission,omitempty"`
 	//Flags are conditional Entry that only applies in certain situations
 	Flags map[string]FlagEntry `json:"flags,omitempty"`
+	//Revision is increased by providers that track revisions every time the group is stored, starting from 1
+	//it's managed by the provider, the value given when storing a group is ignored
+	Revision uint64 `json:"revision,omitempty"`
 }
 
 //Entry represent a collection of permissions and flags
@@ -22,14 +28,33 @@ type Entry struct {
 	//EmptySet will discard all previously granted permissions
 	EmptySet bool `json:"empty_set,omitempty"`
 	//Level is the default power level of said entry
-	//Only the highest group's level is in used
+	//how levels of multiple Entry are combined is decided by BasicProcessor.Merge
 	Level int `json:"level,omitempty"`
+	//Levels are node scoped levels keyed by node prefix, such as "chat.moderate"
+	//they follow the same SetLevel rules as Level, but are tracked separately from it
+	//a scoped level that was never set before starts at 0
+	Levels map[string]int `json:"levels,omitempty"`
 	//SetLevel makes overwrites the Level instead of adding or subtracting from last level
+	//also applies to Levels
 	SetLevel bool `json:"set_level,omitempty"`
 	//Grant will add permissions to the List
 	Grant []string `json:"grant,omitempty"`
 	//Revoke will revoke a permissions that is granted to the List by a prior group
 	Revoke []string `json:"revoke,omitempty"`
+	//Include are names of Bundle to be expanded into Grant and Revoke, see BasicProcessor.Bundles
+	Include []string `json:"include,omitempty"`
+}
+
+//Bundle is a named reusable set of grants and revokes that an Entry can include
+type Bundle struct {
+	//Name is the unique name used by Entry.Include
+	Name string `json:"name"`
+	//Include are names of other Bundle to be expanded before this one
+	Include []string `json:"include,omitempty"`
+	//Grant are nodes granted by every Entry including this bundle
+	Grant []string `json:"grant,omitempty"`
+	//Revoke are nodes revoked by every Entry including this bundle
+	Revoke []string `json:"revoke,omitempty"`
 }
 
 //FlagEntry is an Entry but inside a Group.Flags
@@ -52,12 +77,21 @@ type RawList struct {
 	Groups []string `json:"groups,omitempty"`
 	//Flags are conditional overwrites for said raw list
 	Flags map[string]FlagEntry `json:"flags,omitempty"`
+	//Tenant scopes Groups to a tenant, groups are resolved within the tenant first, see TenantProvider
+	//"" resolves Groups without a tenant
+	Tenant string `json:"tenant,omitempty"`
 }
 
 //List is the compiled result from a RawList
 type List struct {
 	//Level is the final applicable level
 	Level int `json:"level,omitempty"`
+	//Levels are the final node scoped levels keyed by node prefix
+	//the most specific matching scoped level takes precedent over Level
+	Levels map[string]int `json:"levels,omitempty"`
 	//Permission is th final applicable permission
 	Permission []string `json:"permission,omitempty"`
+	//Exclude are nodes carved out of a wildcard Permission by a later revoke
+	//only produced by BasicProcessor when BasicProcessor.Wildcard is set, and only respected by ImplicitComparator
+	Exclude []string `json:"exclude,omitempty"`
 }
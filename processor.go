@@ -15,13 +15,48 @@ type Processor interface {
 	MergeEntry(l List, es ...Entry) List
 }
 
+//EntryExpander is a Processor that's capable of expanding Entry.Include
+type EntryExpander interface {
+	//ExpandEntry returns a copy of Entry with Entry.Include expanded, returns error if a bundle can't be expanded
+	ExpandEntry(e Entry) (Entry, error)
+}
+
 var _ Processor = (*BasicProcessor)(nil)
+var _ EntryExpander = (*BasicProcessor)(nil)
 
 type BasicProcessor struct {
 	Provider GroupProvider
 	//WeightAscending controls whether smaller or bigger number holds precedent
 	//by default the larger will overwrite the smaller
 	WeightAscending bool
+	//Normalizer is applied to granted and revoked nodes, and to scoped level keys
+	//defaults to keeping nodes as is
+	Normalizer Normalizer
+	//Wildcard enables wildcard aware revoking, following the Deliminator and Terminator rules of the ImplicitComparator
+	//revoking a wildcard such as foo* removes every grant it covers, such as foo.bar and foo.bar*
+	//revoking a node that's still covered by a granted wildcard records it into List.Exclude
+	//a later grant removes all exclusions it covers
+	//by default only exact matches are revoked
+	Wildcard *ImplicitComparator
+	//Merge decides how each Entry is merged, defaults to AdditiveMerge
+	Merge MergeStrategy
+	//StrictWeight makes processing fail with WeightConflictError when groups, or selected flags of the same owner share a weight
+	//by default entries sharing a weight are applied in the order they are listed in RawList.Groups or the selected flags
+	StrictWeight bool
+	//Tracks makes only the highest precedent group of each Group.Track in RawList.Groups apply
+	//groups without a track always apply
+	Tracks bool
+	//Bundles is used to expand Entry.Include
+	//defaults to Provider if it implements BundleProvider
+	Bundles BundleProvider
+}
+
+//mergeStrategy returns the MergeStrategy in use
+func (p BasicProcessor) mergeStrategy() MergeStrategy {
+	if p.Merge == nil {
+		return AdditiveMerge{}
+	}
+	return p.Merge
 }
 
 func (p BasicProcessor) compare(i, j int) bool {
@@ -32,65 +67,109 @@ func (p BasicProcessor) compare(i, j int) bool {
 }
 
 func (p BasicProcessor) Process(r RawList) (List, error) {
-	gs, err := p.getGroups(r.Groups)
+	gs, err := p.getGroups(r.Tenant, r.Groups)
 	if err != nil {
 		return List{}, err
 	}
-	sort.Slice(gs, func(i, j int) bool {
-		return p.compare(gs[i].Weight, gs[j].Weight)
-	})
+	if err := p.sortGroups(gs); err != nil {
+		return List{}, err
+	}
+	if p.Tracks {
+		gs = p.filterTracks(gs)
+	}
 
-	var l List
+	b := p.newListBuilder(List{})
 	for _, g := range gs {
-		l = p.processSet(l, g.Permission)
+		if err := b.include(g.Permission); err != nil {
+			return List{}, err
+		}
 	}
-	l = p.processSet(l, r.Overwrites)
-	return l, nil
+	if err := b.include(r.Overwrites); err != nil {
+		return List{}, err
+	}
+	return b.list, nil
 }
 
 func (p BasicProcessor) ProcessFlags(r RawList, flags ...string) (List, error) {
-	gs, err := p.getGroups(r.Groups)
+	gs, err := p.getGroups(r.Tenant, r.Groups)
 	if err != nil {
 		return List{}, err
 	}
-	sort.Slice(gs, func(i, j int) bool {
-		return p.compare(gs[i].Weight, gs[j].Weight)
-	})
+	if err := p.sortGroups(gs); err != nil {
+		return List{}, err
+	}
+	if p.Tracks {
+		gs = p.filterTracks(gs)
+	}
 
-	var l List
+	b := p.newListBuilder(List{})
 	for _, g := range gs {
-		pre, post := p.getFlags(g.Flags, flags)
+		pre, post, err := p.getFlags(g.ID, g.Flags, flags)
+		if err != nil {
+			return List{}, err
+		}
 		for _, v := range pre {
-			l = p.processSet(l, v.Entry)
+			if err := b.include(v.Entry); err != nil {
+				return List{}, err
+			}
+		}
+		if err := b.include(g.Permission); err != nil {
+			return List{}, err
 		}
-		l = p.processSet(l, g.Permission)
 		for _, v := range post {
-			l = p.processSet(l, v.Entry)
+			if err := b.include(v.Entry); err != nil {
+				return List{}, err
+			}
 		}
 	}
 
-	pre, post := p.getFlags(r.Flags, flags)
+	pre, post, err := p.getFlags("", r.Flags, flags)
+	if err != nil {
+		return List{}, err
+	}
 	for _, v := range pre {
-		l = p.processSet(l, v.Entry)
+		if err := b.include(v.Entry); err != nil {
+			return List{}, err
+		}
+	}
+	if err := b.include(r.Overwrites); err != nil {
+		return List{}, err
 	}
-	l = p.processSet(l, r.Overwrites)
 	for _, v := range post {
-		l = p.processSet(l, v.Entry)
+		if err := b.include(v.Entry); err != nil {
+			return List{}, err
+		}
 	}
-	return l, nil
+	return b.list, nil
 }
 
+//MergeEntry applies es on top of l, Entry.Include is not expanded, use ExpandEntry beforehand
 func (p BasicProcessor) MergeEntry(l List, es ...Entry) List {
+	b := p.newListBuilder(l)
 	for _, e := range es {
-		l = p.processSet(l, e)
+		b.apply(e)
 	}
-	return l
+	return b.list
 }
 
-func (p BasicProcessor) getGroups(r []string) ([]Group, error) {
+//getGroups loads the groups, a non empty tenant requires Provider to be a TenantProvider
+func (p BasicProcessor) getGroups(tenant string, r []string) ([]Group, error) {
+	var tp TenantProvider
+	if tenant != "" && len(r) > 0 {
+		var ok bool
+		if tp, ok = p.Provider.(TenantProvider); !ok {
+			return []Group{}, NewMissingGroupsError(r[0], NewUnsupportedTenantError(tenant))
+		}
+	}
 	var gs []Group
 	for _, gid := range r {
-		v, err := p.Provider.Group(gid)
+		var v Group
+		var err error
+		if tp != nil {
+			v, err = tp.TenantGroup(tenant, gid)
+		} else {
+			v, err = p.Provider.Group(gid)
+		}
 		if err == nil {
 			gs = append(gs, v)
 		} else {
@@ -100,33 +179,107 @@ func (p BasicProcessor) getGroups(r []string) ([]Group, error) {
 	return gs, nil
 }
 
-func (p BasicProcessor) processSet(l List, set Entry) List {
-	if set.SetLevel {
-		l.Level = set.Level
-	} else {
-		l.Level += set.Level
+//sortGroups stable sorts groups by weight, so groups sharing a weight keep their RawList order
+//returns WeightConflictError if BasicProcessor.StrictWeight is set and any groups share a weight
+func (p BasicProcessor) sortGroups(gs []Group) error {
+	sort.SliceStable(gs, func(i, j int) bool {
+		return p.compare(gs[i].Weight, gs[j].Weight)
+	})
+	if !p.StrictWeight {
+		return nil
 	}
+	for i := 0; i < len(gs); {
+		j := i + 1
+		var ids []string
+		for ; j < len(gs) && gs[j].Weight == gs[i].Weight; j++ {
+			if gs[j].ID != gs[i].ID {
+				ids = appendUnique(ids, gs[j].ID)
+			}
+		}
+		if len(ids) > 0 {
+			return NewGroupWeightConflictError(gs[i].Weight, append([]string{gs[i].ID}, ids...))
+		}
+		i = j
+	}
+	return nil
+}
 
-	if set.EmptySet {
-		l.Permission = []string{}
-	} else if len(set.Revoke) > 0 {
-		l.Permission = p.removeNodes(l.Permission, set.Revoke)
+//filterTracks drops every tracked group except the highest precedent one of its track
+//gs must be sorted already, the order is kept
+func (p BasicProcessor) filterTracks(gs []Group) []Group {
+	seen := make(map[string]struct{})
+	keep := make([]bool, len(gs))
+	for i := len(gs) - 1; i >= 0; i-- {
+		if gs[i].Track == "" {
+			keep[i] = true
+			continue
+		}
+		if _, ok := seen[gs[i].Track]; ok {
+			continue
+		}
+		seen[gs[i].Track] = struct{}{}
+		keep[i] = true
 	}
-	l.Permission = append(l.Permission, set.Grant...)
-	return l
+	o := make([]Group, 0, len(gs))
+	for i, g := range gs {
+		if keep[i] {
+			o = append(o, g)
+		}
+	}
+	return o
+}
+
+//processSet applies a single Entry on top of List, List will not be altered
+func (p BasicProcessor) processSet(l List, set Entry) List {
+	b := p.newListBuilder(l)
+	b.apply(set)
+	return b.list
+}
+
+//mergeLevels merges scoped levels into a copy of base using the MergeStrategy
+//base will not be altered
+func (p BasicProcessor) mergeLevels(base map[string]int, levels map[string]int, set bool) map[string]int {
+	m := p.mergeStrategy()
+	o := make(map[string]int, len(base)+len(levels))
+	for k, v := range base {
+		o[k] = v
+	}
+	for k, v := range levels {
+		k = p.Normalizer.normalize(k)
+		cur, exists := o[k]
+		o[k] = m.MergeLevel(cur, exists, v, set)
+	}
+	return o
 }
 
 //getFlags tries to get all selected flags from the map then return the sorted slice into preprocess and postprocess
-func (p BasicProcessor) getFlags(flags map[string]FlagEntry, selected []string) (pre []FlagEntry, post []FlagEntry) {
+//flags sharing a weight keep the order they are selected in
+//owner is the ID of the group the flags belong to, or "" for RawList, it's only used for WeightConflictError
+func (p BasicProcessor) getFlags(owner string, flags map[string]FlagEntry, selected []string) (pre []FlagEntry, post []FlagEntry, err error) {
 	fl := make([]FlagEntry, 0, len(selected))
+	names := make([]string, 0, len(selected))
 	for _, sel := range selected {
 		if f, ok := flags[sel]; ok {
 			fl = append(fl, f)
+			names = append(names, sel)
+		}
+	}
+	sort.Stable(flagSorter{p: p, flags: fl, names: names})
+	if p.StrictWeight {
+		for i := 0; i < len(fl); {
+			j := i + 1
+			var clash []string
+			for ; j < len(fl) && fl[j].Weight == fl[i].Weight; j++ {
+				if names[j] != names[i] {
+					clash = appendUnique(clash, names[j])
+				}
+			}
+			if len(clash) > 0 {
+				return nil, nil, NewFlagWeightConflictError(fl[i].Weight, owner, append([]string{names[i]}, clash...))
+			}
+			i = j
 		}
 	}
-	sort.Slice(fl, func(i, j int) bool {
-		return p.compare(fl[i].Weight, fl[j].Weight)
-	})
 	for _, f := range fl {
 		if f.Preprocess {
 			pre = append(pre, f)
@@ -134,25 +287,196 @@ func (p BasicProcessor) getFlags(flags map[string]FlagEntry, selected []string)
 			post = append(post, f)
 		}
 	}
-	return pre, post
+	return pre, post, nil
+}
+
+//flagSorter sorts flags by weight, keeping names in sync
+type flagSorter struct {
+	p     BasicProcessor
+	flags []FlagEntry
+	names []string
+}
+
+func (s flagSorter) Len() int {
+	return len(s.flags)
+}
+
+func (s flagSorter) Less(i, j int) bool {
+	return s.p.compare(s.flags[i].Weight, s.flags[j].Weight)
+}
+
+func (s flagSorter) Swap(i, j int) {
+	s.flags[i], s.flags[j] = s.flags[j], s.flags[i]
+	s.names[i], s.names[j] = s.names[j], s.names[i]
+}
+
+//appendUnique appends v to s if s doesn't contain v yet
+func appendUnique(s []string, v string) []string {
+	for _, e := range s {
+		if e == v {
+			return s
+		}
+	}
+	return append(s, v)
 }
 
 //removeNodes removes needles from a specified stack,
 //inputs will not be altered and are assumed to be nonzero length slices
+//stack nodes are normalized before being compared, needles are assumed to be normalized already
 func (p BasicProcessor) removeNodes(stack []string, needle []string) []string {
-	check := func(v string) bool {
-		for _, r := range needle {
-			if v == r {
-				return false
-			}
-		}
-		return true
-	}
+	set := newNodeSet(needle)
 	ret := make([]string, 0, len(stack))
 	for _, v := range stack {
-		if check(v) {
+		if _, ok := set[p.Normalizer.normalize(v)]; !ok {
 			ret = append(ret, v)
 		}
 	}
 	return ret
 }
+
+var _ NodeApplier = (*listBuilder)(nil)
+
+//listBuilder applies Entry one after another to build a List
+//it keeps an index of granted nodes, so grants are deduplicated without scanning the List
+type listBuilder struct {
+	p     BasicProcessor
+	list  List
+	index map[string]struct{}
+	//levelSet tracks if any Entry has specified the level yet
+	levelSet bool
+}
+
+//newListBuilder creates a listBuilder starting from a copy of l
+//duplicated nodes already in l are dropped, keeping the first one
+//the level of l counts as specified if it's not 0
+func (p BasicProcessor) newListBuilder(l List) *listBuilder {
+	b := &listBuilder{p: p, list: l, index: make(map[string]struct{}, len(l.Permission)), levelSet: l.Level != 0}
+	if l.Permission != nil {
+		b.list.Permission = make([]string, 0, len(l.Permission))
+		b.grant(l.Permission, false)
+	}
+	if l.Exclude != nil {
+		b.list.Exclude = append([]string(nil), l.Exclude...)
+	}
+	return b
+}
+
+//apply merges set into the List that is being built using the MergeStrategy
+func (b *listBuilder) apply(set Entry) {
+	m := b.p.mergeStrategy()
+	b.list.Level = m.MergeLevel(b.list.Level, b.levelSet, set.Level, set.SetLevel)
+	if set.Level != 0 || set.SetLevel {
+		b.levelSet = true
+	}
+	if len(set.Levels) > 0 {
+		b.list.Levels = b.p.mergeLevels(b.list.Levels, set.Levels, set.SetLevel)
+	}
+	m.MergeNodes(b, set)
+}
+
+//include expands set with ExpandEntry, then applies it
+func (b *listBuilder) include(set Entry) error {
+	set, err := b.p.ExpandEntry(set)
+	if err != nil {
+		return err
+	}
+	b.apply(set)
+	return nil
+}
+
+//Nodes returns the currently granted nodes
+func (b *listBuilder) Nodes() []string {
+	return b.list.Permission
+}
+
+//Clear revokes every granted node and exclusion
+func (b *listBuilder) Clear() {
+	b.list.Permission = []string{}
+	b.list.Exclude = nil
+	b.index = make(map[string]struct{})
+}
+
+//Revoke revokes nodes, following BasicProcessor.Wildcard if set
+func (b *listBuilder) Revoke(nodes []string) {
+	p := b.p
+	revoke := p.Normalizer.normalizeAll(nodes)
+	if p.Wildcard != nil {
+		b.revokeWildcard(*p.Wildcard, revoke)
+		return
+	}
+	b.list.Permission = p.removeNodes(b.list.Permission, revoke)
+	for _, n := range revoke {
+		delete(b.index, n)
+	}
+}
+
+//Grant grants nodes that are not granted yet, and removes exclusions they cover if BasicProcessor.Wildcard is set
+func (b *listBuilder) Grant(nodes []string) {
+	p := b.p
+	if p.Wildcard != nil && len(b.list.Exclude) > 0 {
+		b.list.Exclude, _ = b.removeCovered(*p.Wildcard, b.list.Exclude, p.Normalizer.normalizeAll(nodes))
+	}
+	b.grant(nodes, true)
+}
+
+//revokeWildcard removes every grant and exclusion covered by revoke
+//revoked nodes that are still covered by a remaining wildcard grant are added as exclusion
+func (b *listBuilder) revokeWildcard(w ImplicitComparator, revoke []string) {
+	var removed []string
+	b.list.Permission, removed = b.removeCovered(w, b.list.Permission, revoke)
+	for _, n := range removed {
+		delete(b.index, b.p.Normalizer.normalize(n))
+	}
+	b.list.Exclude, _ = b.removeCovered(w, b.list.Exclude, revoke)
+
+	exclude := newNodeSet(b.list.Exclude)
+	var buf []byte
+	for _, r := range revoke {
+		var covered bool
+		if covered, buf = w.hasVariant(b.index, r, buf); !covered {
+			continue
+		}
+		if _, ok := exclude[r]; ok {
+			continue
+		}
+		exclude[r] = struct{}{}
+		b.list.Exclude = append(b.list.Exclude, r)
+	}
+}
+
+//removeCovered splits nodes into the ones that are not covered by any of the wildcards and the ones that are
+//a node is covered if it's equal to a wildcard, or if it's a descendant of it under the ImplicitComparator rules
+//nodes will not be altered
+func (b *listBuilder) removeCovered(w ImplicitComparator, nodes []string, wildcards []string) (kept []string, removed []string) {
+	if len(nodes) == 0 {
+		return nodes, nil
+	}
+	set := newNodeSet(wildcards)
+	kept = make([]string, 0, len(nodes))
+	var buf []byte
+	for _, n := range nodes {
+		var covered bool
+		if covered, buf = w.hasVariant(set, b.p.Normalizer.normalize(n), buf); covered {
+			removed = append(removed, n)
+			continue
+		}
+		kept = append(kept, n)
+	}
+	return kept, removed
+}
+
+//grant appends nodes that aren't granted yet, preserving the order they are first granted in
+//nodes are normalized before being stored if normalize is true, otherwise they are only normalized for the index
+func (b *listBuilder) grant(nodes []string, normalize bool) {
+	for _, n := range nodes {
+		k := b.p.Normalizer.normalize(n)
+		if _, ok := b.index[k]; ok {
+			continue
+		}
+		b.index[k] = struct{}{}
+		if normalize {
+			n = k
+		}
+		b.list.Permission = append(b.list.Permission, n)
+	}
+}
@@ -23,21 +23,25 @@ func (d *dummyProvider) Group(uid string) (Group, error) {
 	return Group{}, errors.New(fmt.Sprintf("group \"%s\" is not defined", uid))
 }
 
-func TestBasicProcessor_Process(t *testing.T) {
-	type fields struct {
-		Groups          []Group
-		WeightAscending bool
-	}
-	tests := []struct {
-		name    string
-		fields  fields
-		r       RawList
-		want    List
-		wantErr bool
-	}{
+type processFields struct {
+	Groups          []Group
+	WeightAscending bool
+}
+
+type processTest struct {
+	name    string
+	fields  processFields
+	r       RawList
+	want    List
+	wantErr bool
+}
+
+//getProcessTests are the shared Process scenarios, also used to test every MergeStrategy
+func getProcessTests() []processTest {
+	return []processTest{
 		{
 			name: "Simple 1",
-			fields: fields{Groups: []Group{
+			fields: processFields{Groups: []Group{
 				{
 					ID: "1", Weight: 1000, Permission: Entry{
 						Level:  10,
@@ -74,7 +78,7 @@ func TestBasicProcessor_Process(t *testing.T) {
 			},
 		}, {
 			name: "Simple 2",
-			fields: fields{Groups: []Group{
+			fields: processFields{Groups: []Group{
 				{
 					ID: "1", Weight: 2, Permission: Entry{
 						Level:  1,
@@ -104,7 +108,7 @@ func TestBasicProcessor_Process(t *testing.T) {
 			wantErr: false,
 		}, {
 			name: "3rd test",
-			fields: fields{
+			fields: processFields{
 				Groups: []Group{
 					{
 						ID:     "-1",
@@ -162,7 +166,7 @@ func TestBasicProcessor_Process(t *testing.T) {
 			wantErr: false,
 		}, {
 			name: "4th Reverse",
-			fields: fields{
+			fields: processFields{
 				WeightAscending: true, Groups: []Group{
 					{
 						ID:     "1",
@@ -206,6 +210,10 @@ func TestBasicProcessor_Process(t *testing.T) {
 			},
 		},
 	}
+}
+
+func TestBasicProcessor_Process(t *testing.T) {
+	tests := getProcessTests()
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			a := assert.New(t)
@@ -226,6 +234,54 @@ func TestBasicProcessor_Process(t *testing.T) {
 			}
 		})
 	}
+	t.Run("Scoped Levels", func(t *testing.T) {
+		a := assert.New(t)
+		p := BasicProcessor{
+			Provider: &dummyProvider{groups: []Group{
+				{ID: "1", Weight: 1, Permission: Entry{Level: 1, Levels: map[string]int{"chat": 10}}},
+				{ID: "2", Weight: 2, Permission: Entry{Level: 2, Levels: map[string]int{"chat": 5, "world": 3}}},
+				{ID: "3", Weight: 3, Permission: Entry{SetLevel: true, Level: 7, Levels: map[string]int{"world": 1}}},
+			}},
+		}
+		got, err := p.Process(RawList{
+			Groups:     []string{"3", "2", "1"},
+			Overwrites: Entry{Levels: map[string]int{"chat.moderate": 4}},
+		})
+		a.NoError(err)
+		a.Equal(7, got.Level)
+		a.Equal(map[string]int{"chat": 15, "chat.moderate": 4, "world": 1}, got.Levels)
+	})
+	t.Run("Deduplicate", func(t *testing.T) {
+		a := assert.New(t)
+		var gs []Group
+		var ids []string
+		for i := 0; i < 5; i++ {
+			id := fmt.Sprint(i)
+			gs = append(gs, Group{ID: id, Weight: i, Permission: Entry{Grant: []string{"chat.send", "g." + id}}})
+			ids = append(ids, id)
+		}
+		p := BasicProcessor{Provider: &dummyProvider{groups: gs}}
+		got, err := p.Process(RawList{Groups: ids, Overwrites: Entry{Grant: []string{"g.0", "self"}}})
+		a.NoError(err)
+		a.Equal([]string{"chat.send", "g.0", "g.1", "g.2", "g.3", "g.4", "self"}, got.Permission)
+	})
+	t.Run("Normalizer", func(t *testing.T) {
+		a := assert.New(t)
+		p := BasicProcessor{
+			Provider: &dummyProvider{groups: []Group{
+				{ID: "1", Weight: 1, Permission: Entry{Grant: []string{"Chat.Send", " chat..kick"}}},
+				{ID: "2", Weight: 2, Permission: Entry{Revoke: []string{"CHAT.KICK"}, Levels: map[string]int{"Chat": 2}}},
+			}},
+			Normalizer: ChainNormalizer(NormalizeTrim, NormalizeLower, NormalizeDeliminator(".")),
+		}
+		got, err := p.Process(RawList{Groups: []string{"1", "2"}})
+		a.NoError(err)
+		a.Equal([]string{"chat.send"}, got.Permission)
+		a.Equal(map[string]int{"chat": 2}, got.Levels)
+
+		got = p.MergeEntry(List{Permission: []string{"Foo.Bar", "baz"}}, Entry{Revoke: []string{"foo.bar"}})
+		a.Equal([]string{"baz"}, got.Permission)
+	})
 	t.Run("Missing", func(t *testing.T) {
 		a := assert.New(t)
 		p := BasicProcessor{
@@ -395,6 +451,179 @@ func TestBasicProcessor_ProcessFlags(t *testing.T) {
 	}
 }
 
+func TestBasicProcessor_EqualWeight(t *testing.T) {
+	groups := []Group{
+		{ID: "a", Weight: 1, Permission: Entry{Grant: []string{"a"}, Revoke: []string{"b"}}},
+		{ID: "b", Weight: 1, Permission: Entry{Grant: []string{"b"}, Revoke: []string{"a"}}},
+		{ID: "c", Weight: 0, Permission: Entry{Grant: []string{"c"}}},
+		{ID: "d", Weight: 1, Permission: Entry{Grant: []string{"d"}}, Flags: map[string]FlagEntry{
+			"f1": {Weight: 1, Entry: Entry{Grant: []string{"f1"}, Revoke: []string{"f2"}}},
+			"f2": {Weight: 1, Entry: Entry{Grant: []string{"f2"}, Revoke: []string{"f1"}}},
+		}},
+	}
+	t.Run("Stable", func(t *testing.T) {
+		a := assert.New(t)
+		p := BasicProcessor{Provider: &dummyProvider{groups: groups}}
+		for i := 0; i < 50; i++ {
+			got, err := p.Process(RawList{Groups: []string{"a", "b", "c"}})
+			a.NoError(err)
+			a.Equal([]string{"c", "b"}, got.Permission)
+
+			got, err = p.Process(RawList{Groups: []string{"b", "c", "a"}})
+			a.NoError(err)
+			a.Equal([]string{"c", "a"}, got.Permission)
+
+			got, err = p.ProcessFlags(RawList{Groups: []string{"d"}}, "f2", "f1")
+			a.NoError(err)
+			a.Equal([]string{"d", "f1"}, got.Permission)
+		}
+	})
+	t.Run("Strict groups", func(t *testing.T) {
+		a := assert.New(t)
+		p := BasicProcessor{Provider: &dummyProvider{groups: groups}, StrictWeight: true}
+		_, err := p.Process(RawList{Groups: []string{"c", "a", "d", "b"}})
+		var e WeightConflictError
+		a.ErrorAs(err, &e)
+		a.False(e.Flag())
+		a.Equal(1, e.Weight())
+		a.Equal("", e.Owner())
+		a.Equal([]string{"a", "d", "b"}, e.Names())
+		a.Equal(`groups "a", "d", "b" share weight 1`, e.Error())
+
+		_, err = p.Process(RawList{Groups: []string{"a", "c", "a"}})
+		a.NoError(err)
+		_, err = p.ProcessFlags(RawList{Groups: []string{"a", "b"}})
+		a.ErrorAs(err, &e)
+	})
+	t.Run("Strict flags", func(t *testing.T) {
+		a := assert.New(t)
+		p := BasicProcessor{Provider: &dummyProvider{groups: groups}, StrictWeight: true}
+		_, err := p.ProcessFlags(RawList{Groups: []string{"d"}}, "f1")
+		a.NoError(err)
+		_, err = p.ProcessFlags(RawList{Groups: []string{"d"}}, "f1", "f2")
+		var e WeightConflictError
+		a.ErrorAs(err, &e)
+		a.True(e.Flag())
+		a.Equal("d", e.Owner())
+		a.Equal([]string{"f1", "f2"}, e.Names())
+		a.Equal(`flags "f1", "f2" of group "d" share weight 1`, e.Error())
+
+		_, err = p.ProcessFlags(RawList{Flags: map[string]FlagEntry{
+			"x": {Weight: 5},
+			"y": {Weight: 5},
+		}}, "y", "x")
+		a.ErrorAs(err, &e)
+		a.Equal("", e.Owner())
+		a.Equal(`raw list flags "y", "x" share weight 5`, e.Error())
+	})
+}
+
+func TestBasicProcessor_Tracks(t *testing.T) {
+	groups := []Group{
+		{ID: "member", Weight: 1, Track: "staff", Permission: Entry{Level: 1, Grant: []string{"staff.member"}}},
+		{ID: "mod", Weight: 2, Track: "staff", Permission: Entry{Level: 5, Grant: []string{"staff.mod"}}},
+		{ID: "admin", Weight: 3, Track: "staff", Permission: Entry{Level: 10, Grant: []string{"staff.admin"}}},
+		{ID: "bronze", Weight: 4, Track: "tier", Permission: Entry{Grant: []string{"tier.bronze"}}},
+		{ID: "gold", Weight: 5, Track: "tier", Permission: Entry{Grant: []string{"tier.gold"}}},
+		{ID: "default", Weight: 0, Permission: Entry{Grant: []string{"default"}}},
+		{ID: "event", Weight: 6, Permission: Entry{Grant: []string{"event"}}},
+	}
+	tests := []struct {
+		name            string
+		tracks          bool
+		weightAscending bool
+		groups          []string
+		want            List
+	}{
+		{
+			name:   "Disabled",
+			groups: []string{"member", "mod", "bronze", "gold"},
+			want:   List{Level: 6, Permission: []string{"staff.member", "staff.mod", "tier.bronze", "tier.gold"}},
+		}, {
+			name:   "Highest per track",
+			tracks: true,
+			groups: []string{"member", "event", "mod", "gold", "default", "bronze"},
+			want:   List{Level: 5, Permission: []string{"default", "staff.mod", "tier.gold", "event"}},
+		}, {
+			name:   "Single tracked",
+			tracks: true,
+			groups: []string{"admin", "default"},
+			want:   List{Level: 10, Permission: []string{"default", "staff.admin"}},
+		}, {
+			name:            "Ascending",
+			tracks:          true,
+			weightAscending: true,
+			groups:          []string{"member", "mod", "bronze", "gold", "event"},
+			want:            List{Level: 1, Permission: []string{"event", "tier.bronze", "staff.member"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := assert.New(t)
+			p := BasicProcessor{
+				Provider:        &dummyProvider{groups: groups},
+				Tracks:          tt.tracks,
+				WeightAscending: tt.weightAscending,
+			}
+			got, err := p.Process(RawList{Groups: tt.groups})
+			a.NoError(err)
+			a.Equal(tt.want, got)
+
+			got, err = p.ProcessFlags(RawList{Groups: tt.groups})
+			a.NoError(err)
+			a.Equal(tt.want, got)
+		})
+	}
+}
+
+//dummyTenantProvider keeps the groups of each tenant, falling back to the groups of dummyProvider
+type dummyTenantProvider struct {
+	dummyProvider
+	tenants map[string]*dummyProvider
+}
+
+func (d *dummyTenantProvider) TenantGroup(tenant string, gid string) (Group, error) {
+	if p, ok := d.tenants[tenant]; ok {
+		if g, err := p.Group(gid); err == nil {
+			return g, nil
+		}
+	}
+	return d.Group(gid)
+}
+
+func TestBasicProcessor_Tenant(t *testing.T) {
+	a := assert.New(t)
+	provider := &dummyTenantProvider{
+		dummyProvider: dummyProvider{groups: []Group{
+			{ID: "member", Weight: 1, Permission: Entry{Grant: []string{"chat"}}},
+			{ID: "mod", Weight: 2, Permission: Entry{Grant: []string{"kick"}}},
+		}},
+		tenants: map[string]*dummyProvider{
+			"acme": {groups: []Group{{ID: "mod", Weight: 2, Permission: Entry{Grant: []string{"ban"}}}}},
+		},
+	}
+	p := BasicProcessor{Provider: provider}
+
+	got, err := p.Process(RawList{Tenant: "acme", Groups: []string{"member", "mod"}})
+	a.NoError(err)
+	a.Equal(List{Permission: []string{"chat", "ban"}}, got)
+	got, err = p.ProcessFlags(RawList{Tenant: "acme", Groups: []string{"mod"}})
+	a.NoError(err)
+	a.Equal(List{Permission: []string{"ban"}}, got)
+	got, err = p.Process(RawList{Groups: []string{"member", "mod"}})
+	a.NoError(err)
+	a.Equal(List{Permission: []string{"chat", "kick"}}, got)
+
+	p.Provider = &provider.dummyProvider
+	_, err = p.Process(RawList{Tenant: "acme", Groups: []string{"mod"}})
+	var e UnsupportedTenantError
+	a.ErrorAs(err, &e)
+	a.Equal("acme", e.Tenant())
+	got, err = p.Process(RawList{Tenant: "acme", Overwrites: Entry{Grant: []string{"chat"}}})
+	a.NoError(err)
+	a.Equal(List{Permission: []string{"chat"}}, got)
+}
+
 func TestBasicProcessor_MergeEntry(t *testing.T) {
 	type args struct {
 		l  List
@@ -504,6 +733,24 @@ func TestBasicProcessor_processSet(t *testing.T) {
 			arg:       Entry{Revoke: []string{"foo", "boo"}, Grant: []string{"far", "boo"}},
 			wantLvl:   l().Level,
 			wantPerms: []string{"bar", "far", "boo"},
+		}, {
+			name:      "Duplicate Grant",
+			base:      l(),
+			arg:       Entry{Grant: []string{"bar", "far", "far"}},
+			wantLvl:   l().Level,
+			wantPerms: []string{"foo", "bar", "far"},
+		}, {
+			name:      "Revoke And Regrant",
+			base:      l(),
+			arg:       Entry{Revoke: []string{"foo"}, Grant: []string{"foo", "bar"}},
+			wantLvl:   l().Level,
+			wantPerms: []string{"bar", "foo"},
+		}, {
+			name:      "Duplicate Base",
+			base:      List{Level: 1, Permission: []string{"foo", "bar", "foo"}},
+			arg:       Entry{Grant: []string{"bar"}},
+			wantLvl:   1,
+			wantPerms: []string{"foo", "bar"},
 		}, {
 			name: "Mixed stuff",
 			base: l(),
@@ -534,6 +781,28 @@ func TestBasicProcessor_processSet(t *testing.T) {
 		})
 	}
 
+	t.Run("Scoped Levels", func(t *testing.T) {
+		a := assert.New(t)
+		ls := List{
+			Level:  10,
+			Levels: map[string]int{"chat": 5, "chat.moderate": 2},
+		}
+		r := p.processSet(ls, Entry{
+			Level:  1,
+			Levels: map[string]int{"chat": 3, "world": -1},
+		})
+		a.Equal(11, r.Level)
+		a.Equal(map[string]int{"chat": 8, "chat.moderate": 2, "world": -1}, r.Levels)
+		a.Equal(map[string]int{"chat": 5, "chat.moderate": 2}, ls.Levels)
+
+		r = p.processSet(r, Entry{
+			SetLevel: true,
+			Levels:   map[string]int{"chat": 1},
+		})
+		a.Equal(0, r.Level)
+		a.Equal(map[string]int{"chat": 1, "chat.moderate": 2, "world": -1}, r.Levels)
+	})
+
 	t.Run("Dirty Test", func(t *testing.T) {
 		a := assert.New(t)
 		ls := List{
@@ -551,6 +820,106 @@ func TestBasicProcessor_processSet(t *testing.T) {
 	})
 }
 
+func TestBasicProcessor_Wildcard(t *testing.T) {
+	w := &ImplicitComparator{Deliminator: ".", Terminator: "*"}
+	tests := []struct {
+		name        string
+		base        List
+		es          []Entry
+		wantPerms   []string
+		wantExclude []string
+		allowed     []string
+		denied      []string
+	}{
+		{
+			name:      "Revoke wildcard",
+			base:      List{Permission: []string{"admin.ban", "admin.kick*", "admin", "administrator", "chat.send"}},
+			es:        []Entry{{Revoke: []string{"admin*"}}},
+			wantPerms: []string{"administrator", "chat.send"},
+			allowed:   []string{"chat.send", "administrator"},
+			denied:    []string{"admin", "admin.ban", "admin.kick.all"},
+		}, {
+			name:        "Revoke child of wildcard",
+			base:        List{Permission: []string{"admin*", "chat.send"}},
+			es:          []Entry{{Revoke: []string{"admin.ban", "chat.send"}}},
+			wantPerms:   []string{"admin*"},
+			wantExclude: []string{"admin.ban"},
+			allowed:     []string{"admin", "admin.kick", "admin.ban.list"},
+			denied:      []string{"admin.ban", "chat.send"},
+		}, {
+			name:        "Revoke child wildcard of wildcard",
+			base:        List{Permission: []string{"admin*", "admin.ban.all"}},
+			es:          []Entry{{Revoke: []string{"admin.ban*"}}},
+			wantPerms:   []string{"admin*"},
+			wantExclude: []string{"admin.ban*"},
+			allowed:     []string{"admin.kick"},
+			denied:      []string{"admin.ban", "admin.ban.all", "admin.ban.list.x"},
+		}, {
+			name:        "Regrant more specific",
+			base:        List{Permission: []string{"admin*"}},
+			es:          []Entry{{Revoke: []string{"admin.ban*"}}, {Grant: []string{"admin.ban.list"}}},
+			wantPerms:   []string{"admin*", "admin.ban.list"},
+			wantExclude: []string{"admin.ban*"},
+			allowed:     []string{"admin.ban.list"},
+			denied:      []string{"admin.ban", "admin.ban.all"},
+		}, {
+			name:      "Regrant clears exclusion",
+			base:      List{Permission: []string{"admin*"}},
+			es:        []Entry{{Revoke: []string{"admin.ban", "admin.kick*"}}, {Grant: []string{"admin.kick*", "admin.ban"}}},
+			wantPerms: []string{"admin*", "admin.kick*", "admin.ban"},
+			allowed:   []string{"admin.ban", "admin.kick.all"},
+		}, {
+			name:      "Revoke parent clears exclusion",
+			base:      List{Permission: []string{"admin*"}},
+			es:        []Entry{{Revoke: []string{"admin.ban"}}, {Revoke: []string{"admin*"}}},
+			wantPerms: []string{},
+			denied:    []string{"admin.ban", "admin.kick"},
+		}, {
+			name:      "Empty set clears exclusion",
+			base:      List{Permission: []string{"admin*"}, Exclude: []string{"admin.ban"}},
+			es:        []Entry{{EmptySet: true, Grant: []string{"admin.ban"}}},
+			wantPerms: []string{"admin.ban"},
+			allowed:   []string{"admin.ban"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := assert.New(t)
+			p := BasicProcessor{Wildcard: w}
+			base := List{
+				Permission: append([]string(nil), tt.base.Permission...),
+				Exclude:    append([]string(nil), tt.base.Exclude...),
+			}
+			got := p.MergeEntry(base, tt.es...)
+			a.Equal(tt.base.Permission, base.Permission)
+			if len(tt.wantPerms) == 0 {
+				a.Empty(got.Permission)
+			} else {
+				a.Equal(tt.wantPerms, got.Permission)
+			}
+			if len(tt.wantExclude) == 0 {
+				a.Empty(got.Exclude)
+			} else {
+				a.Equal(tt.wantExclude, got.Exclude)
+			}
+			for _, n := range tt.allowed {
+				a.True(w.HasPermission(got, n), "%s should be allowed", n)
+				a.True(w.HasAll(got, n), "%s should be allowed", n)
+			}
+			for _, n := range tt.denied {
+				a.False(w.HasPermission(got, n), "%s should be denied", n)
+				a.False(w.HasAny(got, n), "%s should be denied", n)
+			}
+		})
+	}
+	t.Run("Exact revoke by default", func(t *testing.T) {
+		a := assert.New(t)
+		got := BasicProcessor{}.MergeEntry(List{Permission: []string{"admin*", "admin.ban"}}, Entry{Revoke: []string{"admin*", "admin.kick"}})
+		a.Equal([]string{"admin.ban"}, got.Permission)
+		a.Empty(got.Exclude)
+	})
+}
+
 func TestBasicProcessor_removeNodes(t *testing.T) {
 	p := BasicProcessor{WeightAscending: false}
 	tests := []struct {
@@ -741,6 +1110,31 @@ func BenchmarkBasicProcessor_Process(b *testing.B) {
 	}
 }
 
+func BenchmarkBasicProcessor_ProcessLarge(b *testing.B) {
+	for _, arg := range largeSliceArgs {
+		if arg.needles < 0 {
+			continue
+		}
+		shared := randSlice(arg.haystack, arg.txtLen)
+		var gs []Group
+		var ids []string
+		for i := 0; i < 5; i++ {
+			id := fmt.Sprint(i)
+			gs = append(gs, Group{ID: id, Weight: i, Permission: Entry{
+				Grant:  shared,
+				Revoke: randNeedles(shared, arg.needles/5),
+			}})
+			ids = append(ids, id)
+		}
+		p := BasicProcessor{Provider: &dummyProvider{groups: gs}}
+		b.Run(arg.name, func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				_, _ = p.Process(RawList{Groups: ids})
+			}
+		})
+	}
+}
+
 func BenchmarkBasicProcessor_ProcessMulti(b *testing.B) {
 	var entry []Entry
 
@@ -6,3 +6,20 @@ type GroupProvider interface {
 	//returns an error if there's an issue accessing Group
 	Group(gid string) (Group, error)
 }
+
+//BundleProvider is something that's capable of providing a permission Bundle
+type BundleProvider interface {
+	//Bundle will take the bundle name and return the Bundle
+	//returns an error if there's an issue accessing Bundle
+	Bundle(name string) (Bundle, error)
+}
+
+//TenantProvider is a GroupProvider that's capable of providing groups scoped to a tenant
+//Group is used for groups without a tenant
+type TenantProvider interface {
+	GroupProvider
+	//TenantGroup will take the tenant and gid and return the Group of said tenant
+	//implementation may fall back to groups shared by every tenant
+	//returns an error if there's an issue accessing Group
+	TenantGroup(tenant string, gid string) (Group, error)
+}
@@ -0,0 +1,394 @@
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/Thunder33345/roller"
+	"io"
+	"os"
+	"sync"
+	"time"
+)
+
+//AuditOperation is the kind of mutation an AuditRecord is for
+type AuditOperation string
+
+const (
+	AuditAdd    AuditOperation = "add"
+	AuditRemove AuditOperation = "remove"
+)
+
+//AuditRecord is a single mutation recorded by Audited
+type AuditRecord struct {
+	//Actor is who made the change, "" if unknown
+	Actor string `json:"actor"`
+	//Time is when the change was made
+	Time      time.Time      `json:"time"`
+	Operation AuditOperation `json:"operation"`
+	//ID is the ID of the changed group
+	ID string `json:"id"`
+	//Before is the group before the change, nil if it didn't exist
+	Before *roller.Group `json:"before"`
+	//After is the group after the change, nil if it was removed
+	After *roller.Group `json:"after"`
+}
+
+//AuditSink is where AuditRecord are written to
+type AuditSink interface {
+	//Record writes out a record, returns error if the record can't be written
+	Record(r AuditRecord) error
+}
+
+var _ GroupStorer = (*Audited)(nil)
+var _ Reviser = (*Audited)(nil)
+var _ Transactor = (*Audited)(nil)
+var _ Walker = (*Audited)(nil)
+var _ Saver = (*Audited)(nil)
+
+//Audited is a GroupStorer decorator that records every successful change into an AuditSink
+//Reviser, Transactor, Walker and Saver are forwarded to the underlying store, they return UnsupportedError if it doesn't implement them
+//the actor is taken from As, or WithContext with a context from ContextWithActor
+//
+//if the underlying store is a Reviser, changes are written with a revision check, so AuditRecord.Before is exactly what was replaced
+//otherwise, and for RemoveGroup, Before is looked up separately, so it can be stale if there are concurrent writers
+type Audited struct {
+	store GroupStorer
+	sink  AuditSink
+	actor string
+	//now is used for AuditRecord.Time, used for tests
+	now func() time.Time
+}
+
+func NewAudited(store GroupStorer, sink AuditSink) Audited {
+	return Audited{store: store, sink: sink, now: time.Now}
+}
+
+//As returns a copy of Audited that records changes as actor
+func (a Audited) As(actor string) Audited {
+	a.actor = actor
+	return a
+}
+
+//WithContext returns a copy of Audited that records changes as the actor of ctx
+//the actor is kept as is if ctx has no actor
+func (a Audited) WithContext(ctx context.Context) Audited {
+	if actor, ok := ActorFromContext(ctx); ok {
+		a.actor = actor
+	}
+	return a
+}
+
+//Actor returns the actor changes are recorded as
+func (a Audited) Actor() string {
+	return a.actor
+}
+
+func (a Audited) Group(id string) (roller.Group, error) {
+	return a.store.Group(id)
+}
+
+//AddGroup adds the group to the underlying store and records it
+//returns AuditError if the group was added but the record failed to be written
+func (a Audited) AddGroup(group roller.Group) error {
+	if _, ok := a.store.(Reviser); ok {
+		for {
+			before, err := a.current(group.ID)
+			if err != nil {
+				return err
+			}
+			_, err = a.swap(group, before)
+			var ce ConflictError
+			if errors.As(err, &ce) {
+				continue
+			}
+			return err
+		}
+	}
+	before := a.lookup(group.ID)
+	if err := a.store.AddGroup(group); err != nil {
+		return err
+	}
+	after := a.lookup(group.ID)
+	if after == nil {
+		after = &group
+	}
+	return a.record(AuditAdd, group.ID, before, after)
+}
+
+//RemoveGroup removes the group from the underlying store and records it
+//returns AuditError if the group was removed but the record failed to be written
+func (a Audited) RemoveGroup(id string) error {
+	before := a.lookup(id)
+	if err := a.store.RemoveGroup(id); err != nil {
+		return err
+	}
+	return a.record(AuditRemove, id, before, nil)
+}
+
+//CreateGroup creates the group in the underlying Reviser and records it, see Reviser
+//returns AuditError if the group was created but the record failed to be written
+func (a Audited) CreateGroup(group roller.Group) (uint64, error) {
+	rv, ok := a.store.(Reviser)
+	if !ok {
+		return 0, NewUnsupportedError("CreateGroup")
+	}
+	rev, err := rv.CreateGroup(group)
+	if err != nil {
+		return 0, err
+	}
+	group.Revision = rev
+	return rev, a.record(AuditAdd, group.ID, nil, &group)
+}
+
+//UpdateGroup updates the group in the underlying Reviser and records it, see Reviser
+//returns AuditError if the group was updated but the record failed to be written
+func (a Audited) UpdateGroup(group roller.Group) (uint64, error) {
+	if _, ok := a.store.(Reviser); !ok {
+		return 0, NewUnsupportedError("UpdateGroup")
+	}
+	for {
+		before, err := a.current(group.ID)
+		if err != nil {
+			return 0, err
+		}
+		if before == nil {
+			return 0, NewGroupNotFoundError(group.ID)
+		}
+		rev, err := a.swap(group, before)
+		var ce ConflictError
+		if errors.As(err, &ce) {
+			continue
+		}
+		return rev, err
+	}
+}
+
+//CompareAndSwapGroup stores the group in the underlying Reviser if the revision matches and records it, see Reviser
+//returns AuditError if the group was stored but the record failed to be written
+func (a Audited) CompareAndSwapGroup(group roller.Group, expectedRevision uint64) (uint64, error) {
+	if _, ok := a.store.(Reviser); !ok {
+		return 0, NewUnsupportedError("CompareAndSwapGroup")
+	}
+	before, err := a.current(group.ID)
+	if err != nil {
+		return 0, err
+	}
+	var cur uint64
+	if before != nil {
+		cur = before.Revision
+	}
+	if cur != expectedRevision {
+		return 0, NewConflictError(group.ID, expectedRevision, cur)
+	}
+	return a.swap(group, before)
+}
+
+//Update runs fn with a transaction of the underlying Transactor, see Transactor
+//changes staged through tx are recorded once the transaction is committed
+//returns AuditError if the transaction was committed but a record failed to be written, every record is still attempted
+func (a Audited) Update(fn func(tx GroupTx) error) error {
+	t, ok := a.store.(Transactor)
+	if !ok {
+		return NewUnsupportedError("Update")
+	}
+	var records []AuditRecord
+	if err := t.Update(func(tx GroupTx) error {
+		records = nil
+		return fn(&auditedTx{GroupTx: tx, actor: a.actor, records: &records})
+	}); err != nil {
+		return err
+	}
+	var first error
+	now := a.now()
+	for _, r := range records {
+		r.Time = now
+		if err := a.write(r); err != nil && first == nil {
+			first = err
+		}
+	}
+	return first
+}
+
+//WalkGroup walks the groups of the underlying Walker
+func (a Audited) WalkGroup(f func(group roller.Group, last bool) (halt bool)) error {
+	w, ok := a.store.(Walker)
+	if !ok {
+		return NewUnsupportedError("WalkGroup")
+	}
+	return w.WalkGroup(f)
+}
+
+//Save saves the underlying Saver
+func (a Audited) Save() error {
+	s, ok := a.store.(Saver)
+	if !ok {
+		return NewUnsupportedError("Save")
+	}
+	return s.Save()
+}
+
+//swap stores group if its current revision is still the one of before, nil meaning it doesn't exist, and records it
+func (a Audited) swap(group roller.Group, before *roller.Group) (uint64, error) {
+	var expected uint64
+	if before != nil {
+		expected = before.Revision
+	}
+	rev, err := a.store.(Reviser).CompareAndSwapGroup(group, expected)
+	if err != nil {
+		return 0, err
+	}
+	group.Revision = rev
+	return rev, a.record(AuditAdd, group.ID, before, &group)
+}
+
+//current returns the stored group, or nil if it doesn't exist
+func (a Audited) current(id string) (*roller.Group, error) {
+	g, err := a.store.Group(id)
+	if err != nil {
+		var nf GroupNotFoundError
+		if errors.As(err, &nf) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &g, nil
+}
+
+//lookup returns the stored group, or nil if it can't be accessed
+func (a Audited) lookup(id string) *roller.Group {
+	g, err := a.store.Group(id)
+	if err != nil {
+		return nil
+	}
+	return &g
+}
+
+func (a Audited) record(op AuditOperation, id string, before *roller.Group, after *roller.Group) error {
+	return a.write(AuditRecord{Actor: a.actor, Time: a.now(), Operation: op, ID: id, Before: before, After: after})
+}
+
+//write writes r into the sink, wrapping the failure into AuditError
+func (a Audited) write(r AuditRecord) error {
+	if err := a.sink.Record(r); err != nil {
+		return NewAuditError(r, err)
+	}
+	return nil
+}
+
+//auditedTx is the GroupTx of Audited, it collects a record of every staged change
+//the records are written by Audited.Update once the transaction is committed
+type auditedTx struct {
+	GroupTx
+	actor   string
+	records *[]AuditRecord
+}
+
+func (t *auditedTx) AddGroup(group roller.Group) error {
+	before := t.lookup(group.ID)
+	if err := t.GroupTx.AddGroup(group); err != nil {
+		return err
+	}
+	after := t.lookup(group.ID)
+	if after == nil {
+		after = &group
+	}
+	*t.records = append(*t.records, AuditRecord{Actor: t.actor, Operation: AuditAdd, ID: group.ID, Before: before, After: after})
+	return nil
+}
+
+func (t *auditedTx) RemoveGroup(id string) error {
+	before := t.lookup(id)
+	if err := t.GroupTx.RemoveGroup(id); err != nil {
+		return err
+	}
+	*t.records = append(*t.records, AuditRecord{Actor: t.actor, Operation: AuditRemove, ID: id, Before: before})
+	return nil
+}
+
+//lookup returns the group as seen by the transaction, or nil if it can't be accessed
+func (t *auditedTx) lookup(id string) *roller.Group {
+	g, err := t.GroupTx.Group(id)
+	if err != nil {
+		return nil
+	}
+	return &g
+}
+
+type actorKey struct{}
+
+//ContextWithActor returns a copy of ctx carrying actor, used by Audited.WithContext
+func ContextWithActor(ctx context.Context, actor string) context.Context {
+	return context.WithValue(ctx, actorKey{}, actor)
+}
+
+//ActorFromContext returns the actor carried by ctx
+func ActorFromContext(ctx context.Context) (string, bool) {
+	actor, ok := ctx.Value(actorKey{}).(string)
+	return actor, ok
+}
+
+var _ AuditSink = (*JSONLinesSink)(nil)
+
+//JSONLinesSink writes every AuditRecord as a single JSON line
+type JSONLinesSink struct {
+	w io.Writer
+	m sync.Mutex
+}
+
+func NewJSONLinesSink(w io.Writer) *JSONLinesSink {
+	return &JSONLinesSink{w: w}
+}
+
+//NewJSONLinesFileSink opens or creates the file at path for appending only
+//the file will be closed when JSONLinesSink.Close is called
+func NewJSONLinesFileSink(path string) (*JSONLinesSink, error) {
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	return &JSONLinesSink{w: f}, nil
+}
+
+func (s *JSONLinesSink) Record(r AuditRecord) error {
+	b, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+	s.m.Lock()
+	defer s.m.Unlock()
+	_, err = s.w.Write(append(b, '\n'))
+	return err
+}
+
+//Close closes the underlying writer if it's an io.Closer
+func (s *JSONLinesSink) Close() error {
+	s.m.Lock()
+	defer s.m.Unlock()
+	if c, ok := s.w.(io.Closer); ok {
+		return c.Close()
+	}
+	return nil
+}
+
+var _ AuditSink = (*MemorySink)(nil)
+
+//MemorySink keeps every AuditRecord in memory, intended for tests
+type MemorySink struct {
+	records []AuditRecord
+	m       sync.Mutex
+}
+
+func (s *MemorySink) Record(r AuditRecord) error {
+	s.m.Lock()
+	defer s.m.Unlock()
+	s.records = append(s.records, r)
+	return nil
+}
+
+//Records returns a copy of all recorded records, in the order they are recorded
+func (s *MemorySink) Records() []AuditRecord {
+	s.m.Lock()
+	defer s.m.Unlock()
+	return append([]AuditRecord(nil), s.records...)
+}
@@ -0,0 +1,193 @@
+package provider
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/Thunder33345/roller"
+	"github.com/stretchr/testify/require"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+type failSink struct{}
+
+func (failSink) Record(AuditRecord) error {
+	return errors.New("sink failed")
+}
+
+func TestAudited(t *testing.T) {
+	r := require.New(t)
+	j, err := NewJSON(&bytes.Buffer{})
+	r.NoError(err)
+	sink := &MemorySink{}
+	now := time.Date(2021, 1, 2, 3, 4, 5, 0, time.UTC)
+	a := NewAudited(j, sink)
+	a.now = func() time.Time { return now }
+
+	r.NoError(a.As("alice").AddGroup(roller.Group{ID: "100", Name: "first"}))
+	r.NoError(a.WithContext(ContextWithActor(context.Background(), "bob")).AddGroup(roller.Group{ID: "100", Name: "second"}))
+	r.NoError(a.As("carol").WithContext(context.Background()).RemoveGroup("100"))
+
+	var nf GroupNotFoundError
+	r.ErrorAs(a.RemoveGroup("100"), &nf)
+	g, err := a.Group("100")
+	r.ErrorAs(err, &nf)
+	r.Equal(roller.Group{}, g)
+
+	r.Equal([]AuditRecord{
+		{Actor: "alice", Time: now, Operation: AuditAdd, ID: "100", After: &roller.Group{ID: "100", Name: "first", Revision: 1}},
+		{Actor: "bob", Time: now, Operation: AuditAdd, ID: "100",
+			Before: &roller.Group{ID: "100", Name: "first", Revision: 1}, After: &roller.Group{ID: "100", Name: "second", Revision: 2}},
+		{Actor: "carol", Time: now, Operation: AuditRemove, ID: "100", Before: &roller.Group{ID: "100", Name: "second", Revision: 2}},
+	}, sink.Records())
+
+	j.readOnly = true
+	var ro ReadOnlyError
+	r.ErrorAs(a.AddGroup(roller.Group{ID: "101"}), &ro)
+	r.Len(sink.Records(), 3, "failed changes should not be recorded")
+	j.readOnly = false
+
+	f := NewAudited(j, failSink{}).As("dave")
+	err = f.AddGroup(roller.Group{ID: "101"})
+	var ae AuditError
+	r.ErrorAs(err, &ae)
+	r.Equal("dave", ae.Record().Actor)
+	r.Equal(`group ID "101" was changed, but failed to record add: sink failed`, ae.Error())
+	_, err = j.Group("101")
+	r.NoError(err)
+}
+
+func TestAudited_Reviser(t *testing.T) {
+	r := require.New(t)
+	j, err := NewJSON(&bytes.Buffer{})
+	r.NoError(err)
+	sink := &MemorySink{}
+	now := time.Date(2021, 1, 2, 3, 4, 5, 0, time.UTC)
+	a := NewAudited(j, sink).As("alice")
+	a.now = func() time.Time { return now }
+
+	rev, err := a.CreateGroup(roller.Group{ID: "100", Name: "first"})
+	r.NoError(err)
+	r.Equal(uint64(1), rev)
+	_, err = a.CreateGroup(roller.Group{ID: "100"})
+	r.ErrorAs(err, &GroupExistsError{})
+
+	rev, err = a.UpdateGroup(roller.Group{ID: "100", Name: "second"})
+	r.NoError(err)
+	r.Equal(uint64(2), rev)
+	_, err = a.UpdateGroup(roller.Group{ID: "101"})
+	r.ErrorAs(err, &GroupNotFoundError{})
+
+	_, err = a.CompareAndSwapGroup(roller.Group{ID: "100", Name: "stale"}, 1)
+	var ce ConflictError
+	r.ErrorAs(err, &ce)
+	r.Equal(uint64(2), ce.Actual())
+	rev, err = a.CompareAndSwapGroup(roller.Group{ID: "100", Name: "third"}, 2)
+	r.NoError(err)
+	r.Equal(uint64(3), rev)
+
+	r.Equal([]AuditRecord{
+		{Actor: "alice", Time: now, Operation: AuditAdd, ID: "100", After: &roller.Group{ID: "100", Name: "first", Revision: 1}},
+		{Actor: "alice", Time: now, Operation: AuditAdd, ID: "100",
+			Before: &roller.Group{ID: "100", Name: "first", Revision: 1}, After: &roller.Group{ID: "100", Name: "second", Revision: 2}},
+		{Actor: "alice", Time: now, Operation: AuditAdd, ID: "100",
+			Before: &roller.Group{ID: "100", Name: "second", Revision: 2}, After: &roller.Group{ID: "100", Name: "third", Revision: 3}},
+	}, sink.Records())
+
+	r.Equal([]string{"100"}, txIDs(r, a))
+	r.NoError(a.Save())
+}
+
+func TestAudited_Update(t *testing.T) {
+	r := require.New(t)
+	j, err := NewJSON(&bytes.Buffer{})
+	r.NoError(err)
+	r.NoError(j.AddGroup(roller.Group{ID: "100"}))
+	sink := &MemorySink{}
+	now := time.Date(2021, 1, 2, 3, 4, 5, 0, time.UTC)
+	a := NewAudited(j, sink).As("alice")
+	a.now = func() time.Time { return now }
+
+	r.Error(a.Update(func(tx GroupTx) error {
+		r.NoError(tx.RemoveGroup("100"))
+		return errors.New("fail")
+	}))
+	r.Empty(sink.Records(), "discarded transaction should not be recorded")
+
+	r.NoError(a.Update(func(tx GroupTx) error {
+		r.NoError(tx.AddGroup(roller.Group{ID: "101"}))
+		return tx.RemoveGroup("100")
+	}))
+	r.Equal([]AuditRecord{
+		{Actor: "alice", Time: now, Operation: AuditAdd, ID: "101", After: &roller.Group{ID: "101", Revision: 1}},
+		{Actor: "alice", Time: now, Operation: AuditRemove, ID: "100", Before: &roller.Group{ID: "100", Revision: 1}},
+	}, sink.Records())
+}
+
+func TestAudited_Unsupported(t *testing.T) {
+	r := require.New(t)
+	j, err := NewJSON(&bytes.Buffer{})
+	r.NoError(err)
+	a := NewAudited(storeOnly{j}, &MemorySink{})
+
+	var ue UnsupportedError
+	_, err = a.CreateGroup(roller.Group{ID: "100"})
+	r.ErrorAs(err, &ue)
+	r.Equal("CreateGroup", ue.Operation())
+	_, err = a.UpdateGroup(roller.Group{ID: "100"})
+	r.ErrorAs(err, &ue)
+	_, err = a.CompareAndSwapGroup(roller.Group{ID: "100"}, 0)
+	r.ErrorAs(err, &ue)
+	r.ErrorAs(a.Update(func(tx GroupTx) error { return nil }), &ue)
+	r.ErrorAs(a.WalkGroup(func(group roller.Group, last bool) bool { return false }), &ue)
+	r.ErrorAs(a.Save(), &ue)
+	r.Equal("underlying provider does not support Save", ue.Error())
+
+	_, err = Import(bytes.NewBufferString(`{"version":1,"groups":[{"id":"100"}]}`), a, nil, ImportMerge)
+	r.NoError(err, "Import should fall back when Update is unsupported")
+	_, err = j.Group("100")
+	r.NoError(err)
+}
+
+func TestJSONLinesSink(t *testing.T) {
+	r := require.New(t)
+	path := filepath.Join(t.TempDir(), "audit.jsonl")
+	now := time.Date(2021, 1, 2, 3, 4, 5, 0, time.UTC)
+	records := []AuditRecord{
+		{Actor: "alice", Time: now, Operation: AuditAdd, ID: "100", After: &roller.Group{ID: "100"}},
+		{Actor: "bob", Time: now, Operation: AuditRemove, ID: "100", Before: &roller.Group{ID: "100"}},
+	}
+
+	for _, rec := range records {
+		s, err := NewJSONLinesFileSink(path)
+		r.NoError(err)
+		r.NoError(s.Record(rec))
+		r.NoError(s.Close())
+	}
+
+	f, err := os.Open(path)
+	r.NoError(err)
+	defer func() {
+		r.NoError(f.Close())
+	}()
+	var got []AuditRecord
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		var rec AuditRecord
+		r.NoError(json.Unmarshal(sc.Bytes(), &rec))
+		got = append(got, rec)
+	}
+	r.NoError(sc.Err())
+	r.Equal(records, got, "file should be appended to")
+
+	buf := &bytes.Buffer{}
+	s := NewJSONLinesSink(buf)
+	r.NoError(s.Record(AuditRecord{Actor: "a", Time: now, Operation: AuditAdd, ID: "1"}))
+	r.Equal(`{"actor":"a","time":"2021-01-02T03:04:05Z","operation":"add","id":"1","before":null,"after":null}`+"\n", buf.String())
+	r.NoError(s.Close())
+}
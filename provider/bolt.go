@@ -0,0 +1,275 @@
+package provider
+
+import (
+	"encoding/json"
+	"github.com/Thunder33345/roller"
+	"go.etcd.io/bbolt"
+	"time"
+)
+
+var _ Walker = (*Bolt)(nil)
+var _ Reviser = (*Bolt)(nil)
+var _ Closer = (*Bolt)(nil)
+var _ Lister = (*Bolt)(nil)
+
+var (
+	boltGroups   = []byte("groups")
+	boltRefNames = []byte("ref_names")
+)
+
+//boltPageSize is how many groups WalkGroup reads per transaction
+const boltPageSize = 128
+
+//Bolt is a provider backed by a single bbolt file, suited for large amount of groups
+//groups are stored keyed by ID, with an index of Group.RefName
+//every change is its own transaction and is written to disk before returning, so there's no Save
+type Bolt struct {
+	db       *bbolt.DB
+	readOnly bool
+}
+
+//NewBolt opens or creates the bbolt file at path
+//a read only Bolt can be opened by multiple processes, but the file must exist
+func NewBolt(path string, readOnly bool) (*Bolt, error) {
+	db, err := bbolt.Open(path, 0o600, &bbolt.Options{Timeout: time.Second, ReadOnly: readOnly})
+	if err != nil {
+		return nil, err
+	}
+	b := &Bolt{db: db, readOnly: readOnly}
+	if readOnly {
+		return b, nil
+	}
+	if err := db.Update(func(tx *bbolt.Tx) error {
+		if _, err := tx.CreateBucketIfNotExists(boltGroups); err != nil {
+			return err
+		}
+		_, err := tx.CreateBucketIfNotExists(boltRefNames)
+		return err
+	}); err != nil {
+		_ = db.Close()
+		return nil, err
+	}
+	return b, nil
+}
+
+func (b *Bolt) Group(id string) (roller.Group, error) {
+	var g roller.Group
+	err := b.db.View(func(tx *bbolt.Tx) error {
+		var err error
+		g, err = b.get(tx, id)
+		return err
+	})
+	return g, err
+}
+
+//GroupByRefName returns the group with the given Group.RefName
+func (b *Bolt) GroupByRefName(refName string) (roller.Group, error) {
+	var g roller.Group
+	err := b.db.View(func(tx *bbolt.Tx) error {
+		var id []byte
+		if bk := tx.Bucket(boltRefNames); bk != nil {
+			id = bk.Get([]byte(refName))
+		}
+		if id == nil {
+			return NewRefNameNotFoundError(refName)
+		}
+		var err error
+		g, err = b.get(tx, string(id))
+		return err
+	})
+	return g, err
+}
+
+func (b *Bolt) AddGroup(group roller.Group) error {
+	return b.update(func(tx *bbolt.Tx) error {
+		_, err := b.put(tx, group)
+		return err
+	})
+}
+
+func (b *Bolt) CreateGroup(group roller.Group) (uint64, error) {
+	var rev uint64
+	err := b.update(func(tx *bbolt.Tx) error {
+		if tx.Bucket(boltGroups).Get([]byte(group.ID)) != nil {
+			return NewGroupExistsError(group.ID)
+		}
+		var err error
+		rev, err = b.put(tx, group)
+		return err
+	})
+	return rev, err
+}
+
+func (b *Bolt) UpdateGroup(group roller.Group) (uint64, error) {
+	var rev uint64
+	err := b.update(func(tx *bbolt.Tx) error {
+		if tx.Bucket(boltGroups).Get([]byte(group.ID)) == nil {
+			return NewGroupNotFoundError(group.ID)
+		}
+		var err error
+		rev, err = b.put(tx, group)
+		return err
+	})
+	return rev, err
+}
+
+func (b *Bolt) CompareAndSwapGroup(group roller.Group, expectedRevision uint64) (uint64, error) {
+	var rev uint64
+	err := b.update(func(tx *bbolt.Tx) error {
+		var cur uint64
+		if old, err := b.get(tx, group.ID); err == nil {
+			cur = old.Revision
+		} else if _, ok := err.(GroupNotFoundError); !ok {
+			return err
+		}
+		if cur != expectedRevision {
+			return NewConflictError(group.ID, expectedRevision, cur)
+		}
+		var err error
+		rev, err = b.put(tx, group)
+		return err
+	})
+	return rev, err
+}
+
+func (b *Bolt) RemoveGroup(id string) error {
+	return b.update(func(tx *bbolt.Tx) error {
+		old, err := b.get(tx, id)
+		if err != nil {
+			return err
+		}
+		if old.RefName != "" {
+			if err := tx.Bucket(boltRefNames).Delete([]byte(old.RefName)); err != nil {
+				return err
+			}
+		}
+		return tx.Bucket(boltGroups).Delete([]byte(id))
+	})
+}
+
+//WalkGroup walks every group ordered by ID, see WalkGroupFrom
+func (b *Bolt) WalkGroup(f func(group roller.Group, last bool) (halt bool)) error {
+	return b.WalkGroupFrom("", f)
+}
+
+//WalkGroupFrom walks every group with an ID ordered after the given ID, ordered by ID
+//an iteration can be resumed by passing the ID of the last walked group
+//groups are read in small transactions, so the callback may change Bolt, changes after the current ID will be walked
+func (b *Bolt) WalkGroupFrom(after string, f func(group roller.Group, last bool) (halt bool)) error {
+	cursor := after
+	for {
+		var page []roller.Group
+		var more bool
+		err := b.db.View(func(tx *bbolt.Tx) error {
+			bk := tx.Bucket(boltGroups)
+			if bk == nil {
+				return nil
+			}
+			c := bk.Cursor()
+			k, v := c.Seek([]byte(cursor))
+			if k != nil && string(k) == cursor {
+				k, v = c.Next()
+			}
+			for ; k != nil; k, v = c.Next() {
+				if len(page) == boltPageSize {
+					more = true
+					return nil
+				}
+				var g roller.Group
+				if err := json.Unmarshal(v, &g); err != nil {
+					return err
+				}
+				page = append(page, g)
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		for i, g := range page {
+			if f(g, !more && len(page)-1 == i) {
+				return nil
+			}
+		}
+		if !more {
+			return nil
+		}
+		cursor = page[len(page)-1].ID
+	}
+}
+
+//ListGroups pages through the groups, see Lister
+func (b *Bolt) ListGroups(opts ListOptions) (GroupPage, error) {
+	p := pager{opts: opts}
+	err := b.WalkGroupFrom(opts.Cursor, func(group roller.Group, last bool) bool {
+		return !p.add(group)
+	})
+	return p.page, err
+}
+
+func (b *Bolt) Close() error {
+	return b.db.Close()
+}
+
+//update runs fn in a write transaction
+func (b *Bolt) update(fn func(tx *bbolt.Tx) error) error {
+	if b.readOnly {
+		return ReadOnlyError{}
+	}
+	return b.db.Update(fn)
+}
+
+//get reads a group inside tx
+func (b *Bolt) get(tx *bbolt.Tx, id string) (roller.Group, error) {
+	var v []byte
+	if bk := tx.Bucket(boltGroups); bk != nil {
+		v = bk.Get([]byte(id))
+	}
+	if v == nil {
+		return roller.Group{}, NewGroupNotFoundError(id)
+	}
+	var g roller.Group
+	if err := json.Unmarshal(v, &g); err != nil {
+		return roller.Group{}, err
+	}
+	return g, nil
+}
+
+//put upserts group with the next revision inside tx, and keeps the RefName index in sync
+//returns DuplicateRefNameError if another group already has the RefName
+func (b *Bolt) put(tx *bbolt.Tx, group roller.Group) (uint64, error) {
+	groups, refs := tx.Bucket(boltGroups), tx.Bucket(boltRefNames)
+	old, err := b.get(tx, group.ID)
+	exists := err == nil
+	if err != nil {
+		if _, ok := err.(GroupNotFoundError); !ok {
+			return 0, err
+		}
+	}
+
+	if group.RefName != "" {
+		if id := refs.Get([]byte(group.RefName)); id != nil && string(id) != group.ID {
+			return 0, NewDuplicateRefNameError(group.RefName, string(id), group.ID)
+		}
+	}
+	if exists && old.RefName != "" && old.RefName != group.RefName {
+		if err := refs.Delete([]byte(old.RefName)); err != nil {
+			return 0, err
+		}
+	}
+	if group.RefName != "" {
+		if err := refs.Put([]byte(group.RefName), []byte(group.ID)); err != nil {
+			return 0, err
+		}
+	}
+
+	group.Revision = old.Revision + 1
+	v, err := json.Marshal(group)
+	if err != nil {
+		return 0, err
+	}
+	if err := groups.Put([]byte(group.ID), v); err != nil {
+		return 0, err
+	}
+	return group.Revision, nil
+}
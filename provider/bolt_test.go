@@ -0,0 +1,168 @@
+package provider
+
+import (
+	"fmt"
+	"github.com/Thunder33345/roller"
+	"github.com/stretchr/testify/require"
+	"path/filepath"
+	"testing"
+)
+
+func newTestBolt(r *require.Assertions, dir string) *Bolt {
+	b, err := NewBolt(filepath.Join(dir, "groups.db"), false)
+	r.NoError(err)
+	return b
+}
+
+func TestBolt(t *testing.T) {
+	r := require.New(t)
+	dir := t.TempDir()
+	b := newTestBolt(r, dir)
+
+	r.NoError(b.AddGroup(roller.Group{ID: "100", RefName: "member", Permission: roller.Entry{Grant: []string{"chat"}}}))
+	r.NoError(b.AddGroup(roller.Group{ID: "101", RefName: "mod"}))
+	g, err := b.Group("100")
+	r.NoError(err)
+	r.Equal(roller.Group{ID: "100", RefName: "member", Permission: roller.Entry{Grant: []string{"chat"}}, Revision: 1}, g)
+	g, err = b.GroupByRefName("mod")
+	r.NoError(err)
+	r.Equal("101", g.ID)
+
+	var nf GroupNotFoundError
+	_, err = b.Group("102")
+	r.ErrorAs(err, &nf)
+	r.ErrorAs(b.RemoveGroup("102"), &nf)
+	var rnf RefNameNotFoundError
+	_, err = b.GroupByRefName("admin")
+	r.ErrorAs(err, &rnf)
+	r.Equal("admin", rnf.RefName())
+
+	var dre DuplicateRefNameError
+	r.ErrorAs(b.AddGroup(roller.Group{ID: "102", RefName: "mod"}), &dre)
+	r.Equal("101", dre.Original())
+	r.Equal("102", dre.Duplicate())
+	_, err = b.Group("102")
+	r.ErrorAs(err, &nf, "failed write should be rolled back")
+
+	r.NoError(b.AddGroup(roller.Group{ID: "101", RefName: "moderator"}))
+	_, err = b.GroupByRefName("mod")
+	r.ErrorAs(err, &rnf)
+	g, err = b.GroupByRefName("moderator")
+	r.NoError(err)
+	r.Equal(uint64(2), g.Revision)
+	r.NoError(b.AddGroup(roller.Group{ID: "102", RefName: "mod"}), "old RefName should be freed")
+
+	r.NoError(b.RemoveGroup("102"))
+	_, err = b.GroupByRefName("mod")
+	r.ErrorAs(err, &rnf)
+
+	l, err := roller.BasicProcessor{Provider: b}.Process(roller.RawList{Groups: []string{"100"}})
+	r.NoError(err)
+	r.Equal([]string{"chat"}, l.Permission)
+
+	r.NoError(b.Close())
+	b = newTestBolt(r, dir)
+	g, err = b.Group("101")
+	r.NoError(err)
+	r.Equal("moderator", g.RefName, "changes should persist")
+	r.NoError(b.Close())
+
+	ro, err := NewBolt(filepath.Join(dir, "groups.db"), true)
+	r.NoError(err)
+	_, err = ro.Group("100")
+	r.NoError(err)
+	var roe ReadOnlyError
+	r.ErrorAs(ro.AddGroup(roller.Group{ID: "103"}), &roe)
+	r.ErrorAs(ro.RemoveGroup("100"), &roe)
+	r.NoError(ro.Close())
+}
+
+func TestBolt_Revisions(t *testing.T) {
+	r := require.New(t)
+	b := newTestBolt(r, t.TempDir())
+	defer func() {
+		r.NoError(b.Close())
+	}()
+
+	rev, err := b.CreateGroup(roller.Group{ID: "100"})
+	r.NoError(err)
+	r.Equal(uint64(1), rev)
+	var ee GroupExistsError
+	_, err = b.CreateGroup(roller.Group{ID: "100"})
+	r.ErrorAs(err, &ee)
+
+	rev, err = b.UpdateGroup(roller.Group{ID: "100", Name: "a"})
+	r.NoError(err)
+	r.Equal(uint64(2), rev)
+	var nf GroupNotFoundError
+	_, err = b.UpdateGroup(roller.Group{ID: "101"})
+	r.ErrorAs(err, &nf)
+
+	rev, err = b.CompareAndSwapGroup(roller.Group{ID: "100", Name: "b"}, 2)
+	r.NoError(err)
+	r.Equal(uint64(3), rev)
+	var ce ConflictError
+	_, err = b.CompareAndSwapGroup(roller.Group{ID: "100", Name: "c"}, 2)
+	r.ErrorAs(err, &ce)
+	r.Equal(uint64(3), ce.Actual())
+	rev, err = b.CompareAndSwapGroup(roller.Group{ID: "101"}, 0)
+	r.NoError(err)
+	r.Equal(uint64(1), rev)
+}
+
+func TestBolt_WalkGroup(t *testing.T) {
+	r := require.New(t)
+	b := newTestBolt(r, t.TempDir())
+	defer func() {
+		r.NoError(b.Close())
+	}()
+	r.NoError(b.WalkGroup(func(group roller.Group, last bool) bool {
+		r.Fail("empty Bolt should not be walked")
+		return false
+	}))
+
+	total := boltPageSize*2 + 5
+	for i := total - 1; i >= 0; i-- {
+		r.NoError(b.AddGroup(roller.Group{ID: fmt.Sprintf("%04d", i)}))
+	}
+
+	var ids []string
+	lasts := 0
+	r.NoError(b.WalkGroup(func(group roller.Group, last bool) bool {
+		ids = append(ids, group.ID)
+		if last {
+			lasts++
+		}
+		return false
+	}))
+	r.Len(ids, total)
+	r.Equal(1, lasts)
+	for i, id := range ids {
+		r.Equal(fmt.Sprintf("%04d", i), id, "should be ordered by ID")
+	}
+
+	//walk in halves, resuming from the last walked ID
+	var first []string
+	r.NoError(b.WalkGroup(func(group roller.Group, last bool) bool {
+		first = append(first, group.ID)
+		return len(first) == 150
+	}))
+	var rest []string
+	r.NoError(b.WalkGroupFrom(first[len(first)-1], func(group roller.Group, last bool) bool {
+		rest = append(rest, group.ID)
+		return false
+	}))
+	r.Equal(ids, append(first, rest...))
+
+	//changing Bolt inside the callback should not deadlock
+	r.NoError(b.WalkGroup(func(group roller.Group, last bool) bool {
+		r.NoError(b.RemoveGroup(group.ID))
+		return false
+	}))
+	var left int
+	r.NoError(b.WalkGroup(func(group roller.Group, last bool) bool {
+		left++
+		return false
+	}))
+	r.Zero(left)
+}
@@ -0,0 +1,183 @@
+package provider
+
+import (
+	"container/list"
+	"errors"
+	"fmt"
+	"github.com/Thunder33345/roller"
+	"sync"
+	"time"
+)
+
+var _ roller.GroupProvider = (*Cached)(nil)
+var _ roller.BundleProvider = (*Cached)(nil)
+
+//Cached is a read through cache for any roller.GroupProvider, intended for slow providers
+//concurrent lookups of the same uncached ID share a single call to the provider
+//changes made to the provider directly are only seen after the entry expires or is invalidated
+type Cached struct {
+	provider roller.GroupProvider
+	//ttl is how long a group is cached for, 0 never expires
+	ttl time.Duration
+	//negativeTTL is how long a GroupNotFoundError is cached for, 0 disables negative caching
+	negativeTTL time.Duration
+	//size is the max amount of cached entries, the least recently used is evicted first, 0 is unbounded
+	size int
+
+	m       sync.Mutex
+	entries map[string]*list.Element
+	lru     *list.List
+	calls   map[string]*cacheCall
+	//gen is increased by InvalidateAll, so calls started before it are not cached
+	gen uint64
+	//now is used for expiry, used for tests
+	now func() time.Time
+}
+
+//cacheEntry is a cached result, kept in Cached.lru
+type cacheEntry struct {
+	id      string
+	group   roller.Group
+	err     error
+	expires time.Time
+}
+
+//cacheCall is an in flight call to the provider
+type cacheCall struct {
+	done  chan struct{}
+	group roller.Group
+	err   error
+	gen   uint64
+	//stale is set by Invalidate, so the result is not cached
+	stale bool
+}
+
+func NewCached(provider roller.GroupProvider, ttl time.Duration, negativeTTL time.Duration, size int) *Cached {
+	return &Cached{
+		provider:    provider,
+		ttl:         ttl,
+		negativeTTL: negativeTTL,
+		size:        size,
+		entries:     make(map[string]*list.Element),
+		lru:         list.New(),
+		calls:       make(map[string]*cacheCall),
+		now:         time.Now,
+	}
+}
+
+//Group returns the cached group, or loads it from the provider
+func (c *Cached) Group(id string) (roller.Group, error) {
+	c.m.Lock()
+	if e, ok := c.entries[id]; ok {
+		ent := e.Value.(*cacheEntry)
+		if ent.expires.IsZero() || c.now().Before(ent.expires) {
+			c.lru.MoveToFront(e)
+			c.m.Unlock()
+			return ent.group, ent.err
+		}
+		c.remove(e)
+	}
+	if call, ok := c.calls[id]; ok {
+		c.m.Unlock()
+		<-call.done
+		return call.group, call.err
+	}
+	call := &cacheCall{done: make(chan struct{}), gen: c.gen}
+	c.calls[id] = call
+	c.m.Unlock()
+
+	c.load(id, call)
+	return call.group, call.err
+}
+
+//load calls the provider for call, and caches the result if it's still valid
+func (c *Cached) load(id string, call *cacheCall) {
+	defer func() {
+		if r := recover(); r != nil {
+			call.err = fmt.Errorf("provider panicked: %v", r)
+			c.finish(id, call, false)
+			panic(r)
+		}
+	}()
+	call.group, call.err = c.provider.Group(id)
+	c.finish(id, call, true)
+}
+
+//finish removes the in flight call, caches its result if store is set, then releases the waiters
+func (c *Cached) finish(id string, call *cacheCall, store bool) {
+	c.m.Lock()
+	delete(c.calls, id)
+	if store && !call.stale && call.gen == c.gen {
+		c.store(id, call.group, call.err)
+	}
+	c.m.Unlock()
+	close(call.done)
+}
+
+//Bundle returns the bundle of the provider if it's a roller.BundleProvider, bundles are not cached
+//returns BundleNotFoundError if the provider is not a roller.BundleProvider
+func (c *Cached) Bundle(name string) (roller.Bundle, error) {
+	bp, ok := c.provider.(roller.BundleProvider)
+	if !ok {
+		return roller.Bundle{}, NewBundleNotFoundError(name)
+	}
+	return bp.Bundle(name)
+}
+
+//Invalidate removes the cached entry of id
+//a load of id that's in flight will still be returned to its callers, but won't be cached
+func (c *Cached) Invalidate(id string) {
+	c.m.Lock()
+	defer c.m.Unlock()
+	if e, ok := c.entries[id]; ok {
+		c.remove(e)
+	}
+	if call, ok := c.calls[id]; ok {
+		call.stale = true
+	}
+}
+
+//InvalidateAll removes every cached entry, loads that are in flight won't be cached
+func (c *Cached) InvalidateAll() {
+	c.m.Lock()
+	defer c.m.Unlock()
+	c.entries = make(map[string]*list.Element)
+	c.lru.Init()
+	c.gen++
+}
+
+//Len returns the amount of cached entries, including expired ones that are not evicted yet
+func (c *Cached) Len() int {
+	c.m.Lock()
+	defer c.m.Unlock()
+	return c.lru.Len()
+}
+
+//store caches the result of a provider call, the caller must hold the lock
+func (c *Cached) store(id string, group roller.Group, err error) {
+	ttl := c.ttl
+	if err != nil {
+		var nf GroupNotFoundError
+		if !errors.As(err, &nf) || c.negativeTTL <= 0 {
+			return
+		}
+		ttl = c.negativeTTL
+	}
+	ent := &cacheEntry{id: id, group: group, err: err}
+	if ttl > 0 {
+		ent.expires = c.now().Add(ttl)
+	}
+	if e, ok := c.entries[id]; ok {
+		c.remove(e)
+	}
+	c.entries[id] = c.lru.PushFront(ent)
+	for c.size > 0 && c.lru.Len() > c.size {
+		c.remove(c.lru.Back())
+	}
+}
+
+//remove removes a cached entry, the caller must hold the lock
+func (c *Cached) remove(e *list.Element) {
+	c.lru.Remove(e)
+	delete(c.entries, e.Value.(*cacheEntry).id)
+}
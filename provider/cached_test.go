@@ -0,0 +1,253 @@
+package provider
+
+import (
+	"bytes"
+	"errors"
+	"github.com/Thunder33345/roller"
+	"github.com/stretchr/testify/require"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+//countingProvider counts calls per ID, and blocks every call until release is closed if it's set
+type countingProvider struct {
+	groups  map[string]roller.Group
+	calls   map[string]*int32
+	m       sync.Mutex
+	release chan struct{}
+	started chan string
+	err     error
+}
+
+func newCountingProvider(ids ...string) *countingProvider {
+	p := &countingProvider{groups: make(map[string]roller.Group), calls: make(map[string]*int32)}
+	for _, id := range ids {
+		p.groups[id] = roller.Group{ID: id}
+	}
+	return p
+}
+
+func (p *countingProvider) Group(id string) (roller.Group, error) {
+	p.m.Lock()
+	n, ok := p.calls[id]
+	if !ok {
+		n = new(int32)
+		p.calls[id] = n
+	}
+	g, found := p.groups[id]
+	release, started, err := p.release, p.started, p.err
+	p.m.Unlock()
+	atomic.AddInt32(n, 1)
+	if started != nil {
+		started <- id
+	}
+	if release != nil {
+		<-release
+	}
+	if err != nil {
+		return roller.Group{}, err
+	}
+	if !found {
+		return roller.Group{}, NewGroupNotFoundError(id)
+	}
+	return g, nil
+}
+
+func (p *countingProvider) count(id string) int32 {
+	p.m.Lock()
+	defer p.m.Unlock()
+	if n, ok := p.calls[id]; ok {
+		return atomic.LoadInt32(n)
+	}
+	return 0
+}
+
+func TestCached(t *testing.T) {
+	r := require.New(t)
+	p := newCountingProvider("a", "b")
+	now := time.Unix(0, 0)
+	c := NewCached(p, time.Minute, 10*time.Second, 0)
+	c.now = func() time.Time { return now }
+
+	for i := 0; i < 3; i++ {
+		g, err := c.Group("a")
+		r.NoError(err)
+		r.Equal("a", g.ID)
+	}
+	r.Equal(int32(1), p.count("a"))
+
+	now = now.Add(time.Minute)
+	_, err := c.Group("a")
+	r.NoError(err)
+	r.Equal(int32(2), p.count("a"), "expired entry should be reloaded")
+
+	var nf GroupNotFoundError
+	for i := 0; i < 3; i++ {
+		_, err = c.Group("missing")
+		r.ErrorAs(err, &nf)
+	}
+	r.Equal(int32(1), p.count("missing"), "not found should be cached")
+	now = now.Add(10 * time.Second)
+	_, err = c.Group("missing")
+	r.ErrorAs(err, &nf)
+	r.Equal(int32(2), p.count("missing"))
+
+	c.Invalidate("a")
+	_, err = c.Group("a")
+	r.NoError(err)
+	r.Equal(int32(3), p.count("a"))
+
+	_, err = c.Group("b")
+	r.NoError(err)
+	r.Equal(3, c.Len())
+	c.InvalidateAll()
+	r.Equal(0, c.Len())
+	_, err = c.Group("b")
+	r.NoError(err)
+	r.Equal(int32(2), p.count("b"))
+
+	p.err = errors.New("backend down")
+	c.InvalidateAll()
+	_, err = c.Group("a")
+	r.EqualError(err, "backend down")
+	_, err = c.Group("a")
+	r.EqualError(err, "backend down")
+	r.Equal(int32(5), p.count("a"), "other errors should not be cached")
+}
+
+func TestCached_Options(t *testing.T) {
+	r := require.New(t)
+	p := newCountingProvider("a", "b", "c")
+	now := time.Unix(0, 0)
+	c := NewCached(p, 0, 0, 2)
+	c.now = func() time.Time { return now }
+
+	for _, id := range []string{"a", "b", "a", "c"} {
+		_, err := c.Group(id)
+		r.NoError(err)
+	}
+	r.Equal(2, c.Len())
+	now = now.Add(1000 * time.Hour)
+	for _, id := range []string{"a", "c"} {
+		_, err := c.Group(id)
+		r.NoError(err)
+	}
+	r.Equal(int32(1), p.count("a"), "ttl of 0 should never expire")
+	_, err := c.Group("b")
+	r.NoError(err)
+	r.Equal(int32(2), p.count("b"), "least recently used should be evicted")
+
+	_, err = c.Group("missing")
+	r.Error(err)
+	_, err = c.Group("missing")
+	r.Error(err)
+	r.Equal(int32(2), p.count("missing"), "negative ttl of 0 should not cache")
+}
+
+func TestCached_Bundle(t *testing.T) {
+	r := require.New(t)
+	j, err := NewJSON(&bytes.Buffer{})
+	r.NoError(err)
+	r.NoError(j.AddBundle(roller.Bundle{Name: "chat", Grant: []string{"chat.send"}}))
+	r.NoError(j.AddGroup(roller.Group{ID: "member", Permission: roller.Entry{Include: []string{"chat"}}}))
+	c := NewCached(j, 0, 0, 0)
+
+	l, err := roller.BasicProcessor{Provider: c}.Process(roller.RawList{Groups: []string{"member"}})
+	r.NoError(err)
+	r.Equal([]string{"chat.send"}, l.Permission, "bundles should be forwarded to the provider")
+
+	_, err = NewCached(newCountingProvider(), 0, 0, 0).Bundle("chat")
+	r.Equal(NewBundleNotFoundError("chat"), err)
+}
+
+func TestCached_Singleflight(t *testing.T) {
+	r := require.New(t)
+	p := newCountingProvider("a")
+	p.release = make(chan struct{})
+	p.started = make(chan string, 1)
+	c := NewCached(p, time.Minute, 0, 0)
+
+	var wg sync.WaitGroup
+	results := make([]roller.Group, 10)
+	for i := range results {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			g, err := c.Group("a")
+			if err == nil {
+				results[i] = g
+			}
+		}(i)
+	}
+	<-p.started
+	//give the other goroutines time to join the in flight call
+	time.Sleep(10 * time.Millisecond)
+	close(p.release)
+	wg.Wait()
+	r.Equal(int32(1), p.count("a"))
+	for _, g := range results {
+		r.Equal("a", g.ID)
+	}
+}
+
+func TestCached_InvalidateInFlight(t *testing.T) {
+	for _, all := range []bool{false, true} {
+		r := require.New(t)
+		p := newCountingProvider("a")
+		p.release = make(chan struct{})
+		p.started = make(chan string, 1)
+		c := NewCached(p, time.Minute, 0, 0)
+
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			_, _ = c.Group("a")
+		}()
+		<-p.started
+		if all {
+			c.InvalidateAll()
+		} else {
+			c.Invalidate("a")
+		}
+		close(p.release)
+		<-done
+
+		p.m.Lock()
+		p.release, p.started = nil, nil
+		p.m.Unlock()
+		_, err := c.Group("a")
+		r.NoError(err)
+		r.Equal(int32(2), p.count("a"), "result loaded before invalidation should not be cached")
+	}
+}
+
+func TestCached_Concurrent(t *testing.T) {
+	p := newCountingProvider("a", "b", "c", "d")
+	c := NewCached(p, time.Millisecond, time.Millisecond, 3)
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			ids := []string{"a", "b", "c", "d", "missing"}
+			for j := 0; j < 200; j++ {
+				id := ids[(i+j)%len(ids)]
+				g, err := c.Group(id)
+				if id != "missing" && (err != nil || g.ID != id) {
+					t.Errorf("unexpected result for %v: %v %v", id, g, err)
+					return
+				}
+				switch j % 50 {
+				case 0:
+					c.Invalidate(id)
+				case 25:
+					c.InvalidateAll()
+				}
+			}
+		}(i)
+	}
+	wg.Wait()
+	require.LessOrEqual(t, c.Len(), 3)
+}
@@ -23,6 +23,90 @@ func (e GroupNotFoundError) ID() string {
 	return e.id
 }
 
+var _ error = (*BundleNotFoundError)(nil)
+
+type BundleNotFoundError struct {
+	name string
+}
+
+func NewBundleNotFoundError(name string) BundleNotFoundError {
+	return BundleNotFoundError{name: name}
+}
+
+func (e BundleNotFoundError) Error() string {
+	return fmt.Sprintf("bundle \"%s\" cant be found", e.name)
+}
+
+func (e BundleNotFoundError) Name() string {
+	return e.name
+}
+
+var _ error = (*RawListNotFoundError)(nil)
+
+type RawListNotFoundError struct {
+	subject string
+}
+
+func NewRawListNotFoundError(subject string) RawListNotFoundError {
+	return RawListNotFoundError{subject: subject}
+}
+
+func (e RawListNotFoundError) Error() string {
+	return fmt.Sprintf("raw list of \"%s\" cant be found", e.subject)
+}
+
+func (e RawListNotFoundError) Subject() string {
+	return e.subject
+}
+
+var _ error = (*GroupExistsError)(nil)
+
+type GroupExistsError struct {
+	id string
+}
+
+func NewGroupExistsError(id string) GroupExistsError {
+	return GroupExistsError{id: id}
+}
+
+func (e GroupExistsError) Error() string {
+	return fmt.Sprintf("group ID \"%s\" already exist", e.id)
+}
+
+func (e GroupExistsError) ID() string {
+	return e.id
+}
+
+var _ error = (*ConflictError)(nil)
+
+type ConflictError struct {
+	id       string
+	expected uint64
+	actual   uint64
+}
+
+func NewConflictError(id string, expected uint64, actual uint64) ConflictError {
+	return ConflictError{id: id, expected: expected, actual: actual}
+}
+
+func (e ConflictError) Error() string {
+	return fmt.Sprintf("group ID \"%s\" revision conflict: expected revision %d, got %d", e.id, e.expected, e.actual)
+}
+
+func (e ConflictError) ID() string {
+	return e.id
+}
+
+//Expected returns the revision the caller expected
+func (e ConflictError) Expected() uint64 {
+	return e.expected
+}
+
+//Actual returns the current revision, 0 if the group doesn't exist
+func (e ConflictError) Actual() uint64 {
+	return e.actual
+}
+
 var _ error = (*DuplicateGroupIDError)(nil)
 
 type DuplicateGroupIDError struct {
@@ -47,6 +131,24 @@ func (e DuplicateGroupIDError) Duplicate() roller.Group {
 	return e.g2
 }
 
+var _ error = (*TxDoneError)(nil)
+
+type TxDoneError struct{}
+
+func (e TxDoneError) Error() string {
+	return "transaction is already done"
+}
+
+var _ error = (*TxConflictError)(nil)
+
+//TxConflictError is returned by Transactor.Update when the provider was changed while the transaction ran
+//the transaction is discarded, and can be retried
+type TxConflictError struct{}
+
+func (e TxConflictError) Error() string {
+	return "provider was changed during the transaction"
+}
+
 var _ error = (*ReadOnlyError)(nil)
 
 type ReadOnlyError struct{}
@@ -54,3 +156,217 @@ type ReadOnlyError struct{}
 func (e ReadOnlyError) Error() string {
 	return "provider is set to readonly mode"
 }
+
+var _ error = (*DuplicateBundleNameError)(nil)
+
+type DuplicateBundleNameError struct {
+	name string
+}
+
+func NewDuplicateBundleNameError(name string) DuplicateBundleNameError {
+	return DuplicateBundleNameError{name: name}
+}
+
+func (e DuplicateBundleNameError) Error() string {
+	return fmt.Sprintf("bundle name \"%s\" is not unique", e.name)
+}
+
+func (e DuplicateBundleNameError) Name() string {
+	return e.name
+}
+
+var _ error = (*UnsupportedVersionError)(nil)
+
+type UnsupportedVersionError struct {
+	version   int
+	supported int
+}
+
+func NewUnsupportedVersionError(version int, supported int) UnsupportedVersionError {
+	return UnsupportedVersionError{version: version, supported: supported}
+}
+
+func (e UnsupportedVersionError) Error() string {
+	if e.version < 1 {
+		return fmt.Sprintf("file version %d is invalid, only a bare group array can be version 0", e.version)
+	}
+	return fmt.Sprintf("file version %d is newer than supported version %d", e.version, e.supported)
+}
+
+func (e UnsupportedVersionError) Version() int {
+	return e.version
+}
+
+func (e UnsupportedVersionError) Supported() int {
+	return e.supported
+}
+
+var _ error = (*MigrationError)(nil)
+
+type MigrationError struct {
+	from  int
+	error error
+}
+
+func NewMigrationError(from int, err error) MigrationError {
+	return MigrationError{from: from, error: err}
+}
+
+func (e MigrationError) Error() string {
+	return fmt.Sprintf("failed to migrate from version %d: %v", e.from, e.error)
+}
+
+func (e MigrationError) Unwrap() error {
+	return e.error
+}
+
+func (e MigrationError) From() int {
+	return e.from
+}
+
+var _ error = (*AuditError)(nil)
+
+type AuditError struct {
+	record AuditRecord
+	error  error
+}
+
+func NewAuditError(record AuditRecord, err error) AuditError {
+	return AuditError{record: record, error: err}
+}
+
+func (e AuditError) Error() string {
+	return fmt.Sprintf("group ID \"%s\" was changed, but failed to record %s: %v", e.record.ID, e.record.Operation, e.error)
+}
+
+func (e AuditError) Unwrap() error {
+	return e.error
+}
+
+//Record returns the record that failed to be written
+func (e AuditError) Record() AuditRecord {
+	return e.record
+}
+
+var _ error = (*ImportModeError)(nil)
+
+type ImportModeError struct {
+	mode ImportMode
+}
+
+func NewImportModeError(mode ImportMode) ImportModeError {
+	return ImportModeError{mode: mode}
+}
+
+func (e ImportModeError) Error() string {
+	return fmt.Sprintf("import mode %v requires the destination to be a Walker", e.mode)
+}
+
+func (e ImportModeError) Mode() ImportMode {
+	return e.mode
+}
+
+var _ error = (*LayerError)(nil)
+
+type LayerError struct {
+	layer  int
+	reason string
+}
+
+func NewLayerError(layer int, reason string) LayerError {
+	return LayerError{layer: layer, reason: reason}
+}
+
+func (e LayerError) Error() string {
+	return fmt.Sprintf("invalid writable layer %d: %s", e.layer, e.reason)
+}
+
+func (e LayerError) Layer() int {
+	return e.layer
+}
+
+var _ error = (*RefNameNotFoundError)(nil)
+
+type RefNameNotFoundError struct {
+	refName string
+}
+
+func NewRefNameNotFoundError(refName string) RefNameNotFoundError {
+	return RefNameNotFoundError{refName: refName}
+}
+
+func (e RefNameNotFoundError) Error() string {
+	return fmt.Sprintf("group RefName \"%s\" cant be found", e.refName)
+}
+
+func (e RefNameNotFoundError) RefName() string {
+	return e.refName
+}
+
+var _ error = (*DuplicateRefNameError)(nil)
+
+type DuplicateRefNameError struct {
+	refName   string
+	original  string
+	duplicate string
+}
+
+func NewDuplicateRefNameError(refName string, original string, duplicate string) DuplicateRefNameError {
+	return DuplicateRefNameError{refName: refName, original: original, duplicate: duplicate}
+}
+
+func (e DuplicateRefNameError) Error() string {
+	return fmt.Sprintf("group RefName not unique: \"%s\" is already used by ID \"%s\", cant be shared with ID \"%s\"",
+		e.refName, e.original, e.duplicate)
+}
+
+func (e DuplicateRefNameError) RefName() string {
+	return e.refName
+}
+
+//Original returns the ID of the group that already has the RefName
+func (e DuplicateRefNameError) Original() string {
+	return e.original
+}
+
+//Duplicate returns the ID of the group that failed to be stored
+func (e DuplicateRefNameError) Duplicate() string {
+	return e.duplicate
+}
+
+var _ error = (*InvalidTenantError)(nil)
+
+type InvalidTenantError struct {
+	tenant string
+}
+
+func NewInvalidTenantError(tenant string) InvalidTenantError {
+	return InvalidTenantError{tenant: tenant}
+}
+
+func (e InvalidTenantError) Error() string {
+	return fmt.Sprintf("invalid tenant \"%s\": must be non empty and not contain \"%s\"", e.tenant, TenantSeparator)
+}
+
+func (e InvalidTenantError) Tenant() string {
+	return e.tenant
+}
+
+var _ error = (*UnsupportedError)(nil)
+
+//UnsupportedError is returned by decorators when the underlying provider doesn't support the operation
+type UnsupportedError struct {
+	operation string
+}
+
+func NewUnsupportedError(operation string) UnsupportedError {
+	return UnsupportedError{operation: operation}
+}
+
+func (e UnsupportedError) Error() string {
+	return fmt.Sprintf("underlying provider does not support %s", e.operation)
+}
+
+func (e UnsupportedError) Operation() string {
+	return e.operation
+}
@@ -0,0 +1,359 @@
+package provider
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"github.com/Thunder33345/roller"
+	"io"
+	"reflect"
+	"sort"
+)
+
+//ExportVersion is the version of the export format written by Export
+const ExportVersion = 1
+
+//exportFile is the portable format used by Export and Import
+type exportFile struct {
+	Version int                       `json:"version"`
+	Groups  []roller.Group            `json:"groups"`
+	Bundles []roller.Bundle           `json:"bundles,omitempty"`
+	Lists   map[string]roller.RawList `json:"lists,omitempty"`
+}
+
+//Export writes every group of src, every bundle of src if it's a BundleWalker, and every RawList of lists if it's not nil, into w
+func Export(w io.Writer, src Walker, lists RawListStorer) error {
+	f := exportFile{Version: ExportVersion, Groups: []roller.Group{}}
+	if err := src.WalkGroup(func(group roller.Group, last bool) bool {
+		f.Groups = append(f.Groups, group)
+		return false
+	}); err != nil {
+		return err
+	}
+	if bw, ok := src.(BundleWalker); ok {
+		if err := bw.WalkBundle(func(bundle roller.Bundle, last bool) bool {
+			f.Bundles = append(f.Bundles, bundle)
+			return false
+		}); err != nil {
+			return err
+		}
+	}
+	if lists != nil {
+		f.Lists = make(map[string]roller.RawList)
+		if err := lists.WalkRawList(func(subject string, list roller.RawList, last bool) bool {
+			f.Lists[subject] = list
+			return false
+		}); err != nil {
+			return err
+		}
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "\t")
+	return enc.Encode(f)
+}
+
+//ImportMode decides how Import applies an export, modes can be combined such as ImportReplace|ImportDryRun
+type ImportMode uint8
+
+const (
+	//ImportMerge upserts every imported group and RawList, leaving others as is
+	ImportMerge ImportMode = 0
+	//ImportReplace is ImportMerge, but also removes every group, Bundle and RawList that's not imported
+	//the destination must be a Walker, and a BundleWalker if it's a BundleStorer
+	ImportReplace ImportMode = 1 << 0
+	//ImportDryRun only reports what would be changed, without changing anything
+	ImportDryRun ImportMode = 1 << 1
+)
+
+func (m ImportMode) String() string {
+	s := "merge"
+	if m&ImportReplace != 0 {
+		s = "replace"
+	}
+	if m&ImportDryRun != 0 {
+		s += "|dry-run"
+	}
+	return s
+}
+
+//ImportReport is what Import changed, or would change under ImportDryRun
+type ImportReport struct {
+	//Added are IDs of new groups, in the order they are imported
+	Added []string
+	//Changed are the differences of changed groups keyed by ID
+	//a group with only display fields changed has an empty GroupDiff
+	Changed map[string]roller.GroupDiff
+	//Removed are IDs of removed groups, only used by ImportReplace
+	Removed []string
+	//AddedBundles, ChangedBundles and RemovedBundles are the sorted names of changed Bundle
+	AddedBundles   []string
+	ChangedBundles []string
+	RemovedBundles []string
+	//AddedLists, ChangedLists and RemovedLists are the sorted subjects of changed RawList
+	AddedLists   []string
+	ChangedLists []string
+	RemovedLists []string
+}
+
+//Empty returns true if nothing was changed
+func (r ImportReport) Empty() bool {
+	return len(r.Added) == 0 && len(r.Changed) == 0 && len(r.Removed) == 0 &&
+		len(r.AddedBundles) == 0 && len(r.ChangedBundles) == 0 && len(r.RemovedBundles) == 0 &&
+		len(r.AddedLists) == 0 && len(r.ChangedLists) == 0 && len(r.RemovedLists) == 0
+}
+
+//Import reads an export written by Export from r, and applies it into dst and lists according to mode
+//bundles of the export are ignored if dst is not a BundleStorer, and RawList of the export are ignored if lists is nil
+//groups are applied atomically if dst is a Transactor, otherwise Import stops at the first error
+//returns TxConflictError if dst is a Transactor that kept being changed by others while applying, nothing is applied to the groups then
+//bundles are stored before the groups, and removed after them, so the groups never include a missing bundle
+//returns the report of what was changed, along with any error
+func Import(r io.Reader, dst GroupStorer, lists RawListStorer, mode ImportMode) (ImportReport, error) {
+	b, err := io.ReadAll(r)
+	if err != nil {
+		return ImportReport{}, err
+	}
+	//the version is read first, as a newer export may have fields the strict decode rejects
+	var v struct {
+		Version int `json:"version"`
+	}
+	if err := json.Unmarshal(b, &v); err != nil {
+		return ImportReport{}, err
+	}
+	if v.Version > ExportVersion {
+		return ImportReport{}, NewUnsupportedVersionError(v.Version, ExportVersion)
+	}
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.DisallowUnknownFields()
+	var f exportFile
+	if err := dec.Decode(&f); err != nil {
+		return ImportReport{}, err
+	}
+	seen := make(map[string]int, len(f.Groups))
+	for i, g := range f.Groups {
+		if o, ok := seen[g.ID]; ok {
+			return ImportReport{}, NewDuplicateIDError(f.Groups[o], g)
+		}
+		seen[g.ID] = i
+	}
+	names := make(map[string]struct{}, len(f.Bundles))
+	for _, b := range f.Bundles {
+		if _, ok := names[b.Name]; ok {
+			return ImportReport{}, NewDuplicateBundleNameError(b.Name)
+		}
+		names[b.Name] = struct{}{}
+	}
+
+	var rep ImportReport
+	changed, err := planGroups(&rep, f.Groups, dst, mode)
+	if err != nil {
+		return ImportReport{}, err
+	}
+	bundles, isBundles := dst.(BundleStorer)
+	var changedBundles []roller.Bundle
+	if isBundles {
+		if changedBundles, err = planBundles(&rep, f.Bundles, bundles, mode); err != nil {
+			return ImportReport{}, err
+		}
+	}
+	var changedLists map[string]roller.RawList
+	if lists != nil {
+		if changedLists, err = planLists(&rep, f.Lists, lists, mode); err != nil {
+			return ImportReport{}, err
+		}
+	}
+	if mode&ImportDryRun != 0 {
+		return rep, nil
+	}
+
+	for _, b := range changedBundles {
+		if err := bundles.AddBundle(b); err != nil {
+			return rep, err
+		}
+	}
+	t, ok := dst.(Transactor)
+	if ok {
+		err = t.Update(func(tx GroupTx) error {
+			return applyGroups(tx, changed, rep.Removed)
+		})
+	}
+	var ue UnsupportedError
+	if !ok || errors.As(err, &ue) {
+		err = applyGroups(dst, changed, rep.Removed)
+	}
+	if err != nil {
+		return rep, err
+	}
+	for _, n := range rep.RemovedBundles {
+		if err := bundles.RemoveBundle(n); err != nil {
+			return rep, err
+		}
+	}
+	if lists == nil {
+		return rep, nil
+	}
+	for _, s := range append(append([]string(nil), rep.AddedLists...), rep.ChangedLists...) {
+		if err := lists.SetRawList(s, changedLists[s]); err != nil {
+			return rep, err
+		}
+	}
+	for _, s := range rep.RemovedLists {
+		if err := lists.RemoveRawList(s); err != nil {
+			return rep, err
+		}
+	}
+	return rep, nil
+}
+
+//planGroups fills rep with the group changes, returns the groups to be stored
+func planGroups(rep *ImportReport, groups []roller.Group, dst GroupStorer, mode ImportMode) ([]roller.Group, error) {
+	current := make(map[string]roller.Group)
+	var order []string
+	if mode&ImportReplace != 0 {
+		w, ok := dst.(Walker)
+		if !ok {
+			return nil, NewImportModeError(mode)
+		}
+		if err := w.WalkGroup(func(group roller.Group, last bool) bool {
+			current[group.ID] = group
+			order = append(order, group.ID)
+			return false
+		}); err != nil {
+			var ue UnsupportedError
+			if errors.As(err, &ue) {
+				return nil, NewImportModeError(mode)
+			}
+			return nil, err
+		}
+	} else {
+		for _, g := range groups {
+			c, err := dst.Group(g.ID)
+			if err != nil {
+				var nf GroupNotFoundError
+				if errors.As(err, &nf) {
+					continue
+				}
+				return nil, err
+			}
+			current[g.ID] = c
+		}
+	}
+
+	var changed []roller.Group
+	imported := make(map[string]struct{}, len(groups))
+	for _, g := range groups {
+		imported[g.ID] = struct{}{}
+		c, ok := current[g.ID]
+		if !ok {
+			rep.Added = append(rep.Added, g.ID)
+			changed = append(changed, g)
+			continue
+		}
+		d := roller.DiffGroup(c, g)
+		if d.Empty() && c.Name == g.Name && c.RefName == g.RefName {
+			continue
+		}
+		if rep.Changed == nil {
+			rep.Changed = make(map[string]roller.GroupDiff)
+		}
+		rep.Changed[g.ID] = d
+		changed = append(changed, g)
+	}
+	for _, id := range order {
+		if _, ok := imported[id]; !ok {
+			rep.Removed = append(rep.Removed, id)
+		}
+	}
+	return changed, nil
+}
+
+//planBundles fills rep with the Bundle changes, returns the bundles to be stored
+func planBundles(rep *ImportReport, bundles []roller.Bundle, dst BundleStorer, mode ImportMode) ([]roller.Bundle, error) {
+	var changed []roller.Bundle
+	imported := make(map[string]struct{}, len(bundles))
+	for _, b := range bundles {
+		imported[b.Name] = struct{}{}
+		c, err := dst.Bundle(b.Name)
+		var nf BundleNotFoundError
+		switch {
+		case errors.As(err, &nf):
+			rep.AddedBundles = append(rep.AddedBundles, b.Name)
+		case err != nil:
+			return nil, err
+		case !reflect.DeepEqual(c, b):
+			rep.ChangedBundles = append(rep.ChangedBundles, b.Name)
+		default:
+			continue
+		}
+		changed = append(changed, b)
+	}
+	if mode&ImportReplace != 0 {
+		w, ok := dst.(BundleWalker)
+		if !ok {
+			return nil, NewImportModeError(mode)
+		}
+		if err := w.WalkBundle(func(bundle roller.Bundle, last bool) bool {
+			if _, ok := imported[bundle.Name]; !ok {
+				rep.RemovedBundles = append(rep.RemovedBundles, bundle.Name)
+			}
+			return false
+		}); err != nil {
+			return nil, err
+		}
+	}
+	sort.Strings(rep.AddedBundles)
+	sort.Strings(rep.ChangedBundles)
+	sort.Strings(rep.RemovedBundles)
+	return changed, nil
+}
+
+//planLists fills rep with the RawList changes, returns the RawList to be stored keyed by subject
+func planLists(rep *ImportReport, lists map[string]roller.RawList, dst RawListStorer, mode ImportMode) (map[string]roller.RawList, error) {
+	changed := make(map[string]roller.RawList)
+	for s, l := range lists {
+		c, err := dst.RawList(s)
+		var nf RawListNotFoundError
+		switch {
+		case errors.As(err, &nf):
+			rep.AddedLists = append(rep.AddedLists, s)
+		case err != nil:
+			return nil, err
+		case !reflect.DeepEqual(c, l):
+			rep.ChangedLists = append(rep.ChangedLists, s)
+		default:
+			continue
+		}
+		changed[s] = l
+	}
+	if mode&ImportReplace != 0 {
+		if err := dst.WalkRawList(func(subject string, list roller.RawList, last bool) bool {
+			if _, ok := lists[subject]; !ok {
+				rep.RemovedLists = append(rep.RemovedLists, subject)
+			}
+			return false
+		}); err != nil {
+			return nil, err
+		}
+	}
+	sort.Strings(rep.AddedLists)
+	sort.Strings(rep.ChangedLists)
+	sort.Strings(rep.RemovedLists)
+	return changed, nil
+}
+
+//applyGroups stores changed and removes removed from dst
+func applyGroups(dst GroupStorer, changed []roller.Group, removed []string) error {
+	for _, g := range changed {
+		if err := dst.AddGroup(g); err != nil {
+			return err
+		}
+	}
+	for _, id := range removed {
+		if err := dst.RemoveGroup(id); err != nil {
+			return err
+		}
+	}
+	return nil
+}
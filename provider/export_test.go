@@ -0,0 +1,204 @@
+package provider
+
+import (
+	"bytes"
+	"errors"
+	"github.com/Thunder33345/roller"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+//storeOnly hides every interface other than GroupStorer
+type storeOnly struct {
+	GroupStorer
+}
+
+//brokenStore is a GroupStorer that fails to look up any group
+type brokenStore struct {
+	GroupStorer
+}
+
+func (brokenStore) Group(string) (roller.Group, error) {
+	return roller.Group{}, errors.New("backend down")
+}
+
+//brokenBundles is a JSON that fails to look up any bundle
+type brokenBundles struct {
+	*JSON
+}
+
+func (brokenBundles) Bundle(string) (roller.Bundle, error) {
+	return roller.Bundle{}, errors.New("backend down")
+}
+
+func exportFixture(r *require.Assertions) (*JSON, *MemoryRawLists) {
+	j, err := NewJSON(&bytes.Buffer{})
+	r.NoError(err)
+	r.NoError(j.AddGroup(roller.Group{ID: "member", Weight: 1, Permission: roller.Entry{Grant: []string{"chat"}}}))
+	r.NoError(j.AddGroup(roller.Group{ID: "mod", Weight: 2, Permission: roller.Entry{Grant: []string{"kick"}}}))
+	r.NoError(j.AddGroup(roller.Group{ID: "admin", Weight: 3}))
+	l := &MemoryRawLists{}
+	r.NoError(l.SetRawList("alice", roller.RawList{Groups: []string{"member"}}))
+	r.NoError(l.SetRawList("bob", roller.RawList{Groups: []string{"mod"}}))
+	return j, l
+}
+
+func TestExportImport(t *testing.T) {
+	r := require.New(t)
+	src, srcLists := exportFixture(r)
+	r.NoError(src.AddGroup(roller.Group{ID: "mod", Weight: 2, Permission: roller.Entry{Grant: []string{"kick", "ban"}}}))
+	r.NoError(src.AddGroup(roller.Group{ID: "admin", Name: "Admin", Weight: 3}))
+	r.NoError(src.RemoveGroup("member"))
+	r.NoError(src.AddGroup(roller.Group{ID: "vip", Weight: 4}))
+	r.NoError(srcLists.SetRawList("bob", roller.RawList{Groups: []string{"admin"}}))
+	r.NoError(srcLists.RemoveRawList("alice"))
+	r.NoError(srcLists.SetRawList("carol", roller.RawList{}))
+
+	buf := &bytes.Buffer{}
+	r.NoError(Export(buf, src, srcLists))
+	export := buf.String()
+
+	wantChanged := map[string]roller.GroupDiff{
+		"mod":   {Permission: roller.EntryDiff{AddedGrant: []string{"ban"}}},
+		"admin": {},
+	}
+	tests := []struct {
+		name        string
+		mode        ImportMode
+		want        ImportReport
+		wantGroups  []string
+		wantSubject []string
+	}{
+		{
+			name:        "Merge dry run",
+			mode:        ImportMerge | ImportDryRun,
+			want:        ImportReport{Added: []string{"vip"}, Changed: wantChanged, AddedLists: []string{"carol"}, ChangedLists: []string{"bob"}},
+			wantGroups:  []string{"member", "mod", "admin"},
+			wantSubject: []string{"alice", "bob"},
+		}, {
+			name: "Replace dry run",
+			mode: ImportReplace | ImportDryRun,
+			want: ImportReport{Added: []string{"vip"}, Changed: wantChanged, Removed: []string{"member"},
+				AddedLists: []string{"carol"}, ChangedLists: []string{"bob"}, RemovedLists: []string{"alice"}},
+			wantGroups:  []string{"member", "mod", "admin"},
+			wantSubject: []string{"alice", "bob"},
+		}, {
+			name:        "Merge",
+			mode:        ImportMerge,
+			want:        ImportReport{Added: []string{"vip"}, Changed: wantChanged, AddedLists: []string{"carol"}, ChangedLists: []string{"bob"}},
+			wantGroups:  []string{"member", "mod", "admin", "vip"},
+			wantSubject: []string{"alice", "bob", "carol"},
+		}, {
+			name: "Replace",
+			mode: ImportReplace,
+			want: ImportReport{Added: []string{"vip"}, Changed: wantChanged, Removed: []string{"member"},
+				AddedLists: []string{"carol"}, ChangedLists: []string{"bob"}, RemovedLists: []string{"alice"}},
+			wantGroups:  []string{"mod", "admin", "vip"},
+			wantSubject: []string{"bob", "carol"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := require.New(t)
+			dst, lists := exportFixture(r)
+			got, err := Import(bytes.NewBufferString(export), dst, lists, tt.mode)
+			r.NoError(err)
+			r.Equal(tt.want, got)
+			r.Equal(tt.wantGroups, txIDs(r, dst))
+			var subjects []string
+			r.NoError(lists.WalkRawList(func(subject string, list roller.RawList, last bool) bool {
+				subjects = append(subjects, subject)
+				return false
+			}))
+			r.Equal(tt.wantSubject, subjects)
+
+			if tt.mode&ImportDryRun == 0 {
+				again, err := Import(bytes.NewBufferString(export), dst, lists, tt.mode)
+				r.NoError(err)
+				r.True(again.Empty(), "importing twice should change nothing")
+			}
+		})
+	}
+}
+
+func TestImport_Errors(t *testing.T) {
+	r := require.New(t)
+	dst, _ := exportFixture(r)
+
+	_, err := Import(bytes.NewBufferString(`{"version":1,"groups":[]}`), storeOnly{dst}, nil, ImportReplace|ImportDryRun)
+	var me ImportModeError
+	r.ErrorAs(err, &me)
+	r.Equal("import mode replace|dry-run requires the destination to be a Walker", me.Error())
+
+	_, err = Import(bytes.NewBufferString(`{"version":2,"groups":[]}`), dst, nil, ImportMerge)
+	var ve UnsupportedVersionError
+	r.ErrorAs(err, &ve)
+	r.Equal(ExportVersion, ve.Supported())
+	_, err = Import(bytes.NewBufferString(`{"version":2,"groups":[],"tenants":[]}`), dst, nil, ImportMerge)
+	r.ErrorAs(err, &ve, "version should be checked before unknown fields")
+
+	_, err = Import(bytes.NewBufferString(`{"version":1,"groups":[{"id":"a"},{"id":"a"}]}`), dst, nil, ImportMerge)
+	var de DuplicateGroupIDError
+	r.ErrorAs(err, &de)
+
+	_, err = Import(bytes.NewBufferString(`{"version":1,"groups":[],"unknown":true}`), dst, nil, ImportMerge)
+	r.Error(err)
+
+	got, err := Import(bytes.NewBufferString(`{"version":1,"groups":[{"id":"new"}],"lists":{"x":{}}}`), storeOnly{dst}, nil, ImportMerge)
+	r.NoError(err)
+	r.Equal(ImportReport{Added: []string{"new"}}, got, "lists should be ignored without a store")
+	_, err = dst.Group("new")
+	r.NoError(err)
+
+	_, err = Import(bytes.NewBufferString(`{"version":1,"groups":[{"id":"new"}]}`), brokenStore{dst}, nil, ImportMerge)
+	r.EqualError(err, "backend down", "only a missing group should be treated as added")
+	_, err = Import(bytes.NewBufferString(`{"version":1,"groups":[],"bundles":[{"name":"b"}]}`), brokenBundles{dst}, nil, ImportMerge)
+	r.EqualError(err, "backend down", "only a missing bundle should be treated as added")
+
+	dst.readOnly = true
+	_, err = Import(bytes.NewBufferString(`{"version":1,"groups":[{"id":"other"}]}`), dst, nil, ImportMerge)
+	var ro ReadOnlyError
+	r.ErrorAs(err, &ro)
+}
+
+func TestExportImport_Bundles(t *testing.T) {
+	r := require.New(t)
+	src, err := NewJSON(&bytes.Buffer{})
+	r.NoError(err)
+	r.NoError(src.AddBundle(roller.Bundle{Name: "chat", Grant: []string{"chat.send", "chat.read"}}))
+	r.NoError(src.AddBundle(roller.Bundle{Name: "world", Grant: []string{"world.build"}}))
+	r.NoError(src.AddGroup(roller.Group{ID: "member", Permission: roller.Entry{Include: []string{"chat"}}}))
+
+	buf := &bytes.Buffer{}
+	r.NoError(Export(buf, src, nil))
+	export := buf.String()
+
+	dst, err := NewJSON(&bytes.Buffer{})
+	r.NoError(err)
+	r.NoError(dst.AddBundle(roller.Bundle{Name: "world"}))
+	r.NoError(dst.AddBundle(roller.Bundle{Name: "legacy"}))
+
+	rep, err := Import(bytes.NewBufferString(export), dst, nil, ImportReplace|ImportDryRun)
+	r.NoError(err)
+	r.Equal(ImportReport{Added: []string{"member"}, AddedBundles: []string{"chat"}, ChangedBundles: []string{"world"},
+		RemovedBundles: []string{"legacy"}}, rep)
+	_, err = dst.Bundle("chat")
+	r.ErrorAs(err, &BundleNotFoundError{}, "dry run should not change anything")
+
+	rep, err = Import(bytes.NewBufferString(export), dst, nil, ImportReplace)
+	r.NoError(err)
+	r.Equal([]string{"chat"}, rep.AddedBundles)
+	_, err = dst.Bundle("legacy")
+	r.ErrorAs(err, &BundleNotFoundError{})
+
+	l, err := roller.BasicProcessor{Provider: dst}.Process(roller.RawList{Groups: []string{"member"}})
+	r.NoError(err)
+	r.Equal([]string{"chat.send", "chat.read"}, l.Permission)
+
+	rep, err = Import(bytes.NewBufferString(export), dst, nil, ImportMerge)
+	r.NoError(err)
+	r.True(rep.Empty())
+
+	_, err = Import(bytes.NewBufferString(`{"version":1,"groups":[],"bundles":[{"name":"a"},{"name":"a"}]}`), dst, nil, ImportMerge)
+	r.ErrorAs(err, &DuplicateBundleNameError{})
+}
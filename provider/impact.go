@@ -0,0 +1,106 @@
+package provider
+
+import (
+	"github.com/Thunder33345/roller"
+	"sort"
+	"strings"
+)
+
+//Impact is a subject whose effective permissions would change by a proposed group change
+type Impact struct {
+	//Subject is the key of the RawList given to AnalyzeImpact
+	Subject string
+	//Before and After are the processed List without and with the proposed change
+	Before roller.List
+	After  roller.List
+	//Diff is the difference from Before to After
+	Diff roller.ListDiff
+}
+
+//AnalyzeImpact reports every subject whose effective permissions would change if proposed replaces the group with the same ID
+//a proposed group that doesn't exist yet is added instead
+//groups are read from walker once, so the walker is never altered, and the same snapshot is used for both sides
+//processor is called with a provider of the current groups and a provider of the proposed groups,
+//it should return the Processor to compare under, such as a BasicProcessor with its Provider set
+//the providers are TenantProvider over the walked IDs, where tenant scoped groups have IDs prefixed the same way as Scoped,
+//so walking a store shared by Tenants resolves RawList.Tenant the same way as Tenants, and a tenant scoped group is proposed with its prefixed ID
+//impacts are sorted by Subject, returns error if any subject fails to process
+func AnalyzeImpact(walker Walker, subjects map[string]roller.RawList, proposed roller.Group,
+	processor func(provider roller.GroupProvider) roller.Processor) ([]Impact, error) {
+	current := make(snapshot)
+	var err error
+	werr := walker.WalkGroup(func(group roller.Group, last bool) bool {
+		if _, ok := current[group.ID]; ok {
+			err = NewDuplicateIDError(current[group.ID], group)
+			return true
+		}
+		current[group.ID] = group
+		return false
+	})
+	if werr != nil {
+		return nil, werr
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	next := make(snapshot, len(current)+1)
+	for id, g := range current {
+		next[id] = g
+	}
+	next[proposed.ID] = proposed
+
+	keys := make([]string, 0, len(subjects))
+	for k := range subjects {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	before, after := processor(current), processor(next)
+	var impacts []Impact
+	for _, k := range keys {
+		bl, err := before.Process(subjects[k])
+		if err != nil {
+			return nil, err
+		}
+		al, err := after.Process(subjects[k])
+		if err != nil {
+			return nil, err
+		}
+		if d := roller.Diff(bl, al); !d.Empty() {
+			impacts = append(impacts, Impact{Subject: k, Before: bl, After: al, Diff: d})
+		}
+	}
+	return impacts, nil
+}
+
+var _ roller.TenantProvider = (snapshot)(nil)
+
+//snapshot is a read only in memory roller.GroupProvider keyed by the walked IDs
+type snapshot map[string]roller.Group
+
+//Group returns the group with the walked ID
+func (s snapshot) Group(id string) (roller.Group, error) {
+	g, ok := s[id]
+	if !ok {
+		return roller.Group{}, NewGroupNotFoundError(id)
+	}
+	return g, nil
+}
+
+//TenantGroup returns the tenant scoped group with the prefix removed, or the global group, the same way as Tenants
+func (s snapshot) TenantGroup(tenant string, id string) (roller.Group, error) {
+	if err := checkTenant(tenant); err != nil {
+		return roller.Group{}, err
+	}
+	if strings.Contains(id, TenantSeparator) {
+		return roller.Group{}, NewGroupNotFoundError(id)
+	}
+	prefix := tenant + TenantSeparator
+	if g, ok := s[prefix+id]; ok {
+		g.ID = strings.TrimPrefix(g.ID, prefix)
+		g.RefName = strings.TrimPrefix(g.RefName, prefix)
+		return g, nil
+	}
+	return s.Group(id)
+}
@@ -0,0 +1,83 @@
+package provider
+
+import (
+	"bytes"
+	"github.com/Thunder33345/roller"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestAnalyzeImpact(t *testing.T) {
+	r := require.New(t)
+	j, err := NewJSON(&bytes.Buffer{})
+	r.NoError(err)
+	r.NoError(j.AddGroup(roller.Group{ID: "member", Weight: 1, Permission: roller.Entry{Level: 1, Grant: []string{"chat"}}}))
+	r.NoError(j.AddGroup(roller.Group{ID: "mod", Weight: 2, Permission: roller.Entry{Level: 10, Grant: []string{"kick"}}}))
+	r.NoError(j.AddGroup(roller.Group{ID: "muted", Weight: 3, Permission: roller.Entry{Revoke: []string{"chat"}}}))
+
+	subjects := map[string]roller.RawList{
+		"alice": {Groups: []string{"member"}},
+		"bob":   {Groups: []string{"member", "mod"}},
+		"carol": {Groups: []string{"member", "muted"}},
+		"dave":  {Groups: []string{"mod"}},
+	}
+	processor := func(p roller.GroupProvider) roller.Processor {
+		return roller.BasicProcessor{Provider: p}
+	}
+
+	proposed := roller.Group{ID: "member", Weight: 1, Permission: roller.Entry{Level: 2, Grant: []string{"chat", "emote"}}}
+	got, err := AnalyzeImpact(j, subjects, proposed, processor)
+	r.NoError(err)
+	r.Len(got, 3)
+	r.Equal("alice", got[0].Subject)
+	r.Equal(roller.List{Level: 1, Permission: []string{"chat"}}, got[0].Before)
+	r.Equal(roller.List{Level: 2, Permission: []string{"chat", "emote"}}, got[0].After)
+	r.Equal(roller.ListDiff{Added: []string{"emote"}, LevelDelta: 1}, got[0].Diff)
+	r.Equal("bob", got[1].Subject)
+	r.Equal("carol", got[2].Subject)
+
+	g, err := j.Group("member")
+	r.NoError(err)
+	r.Equal(1, g.Permission.Level, "walker should not be altered")
+
+	got, err = AnalyzeImpact(j, subjects, roller.Group{ID: "new", Permission: roller.Entry{Grant: []string{"x"}}}, processor)
+	r.NoError(err)
+	r.Empty(got)
+
+	_, err = AnalyzeImpact(j, map[string]roller.RawList{"eve": {Groups: []string{"ghost"}}}, proposed, processor)
+	var e roller.MissingGroupError
+	r.ErrorAs(err, &e)
+}
+
+func TestAnalyzeImpact_Tenants(t *testing.T) {
+	r := require.New(t)
+	j, err := NewJSON(&bytes.Buffer{})
+	r.NoError(err)
+	r.NoError(j.AddGroup(roller.Group{ID: "member", Weight: 1, Permission: roller.Entry{Grant: []string{"chat"}}}))
+	acme, err := NewScoped(j, "acme", j)
+	r.NoError(err)
+	r.NoError(acme.AddGroup(roller.Group{ID: "mod", Weight: 2, Permission: roller.Entry{Grant: []string{"kick"}}}))
+
+	subjects := map[string]roller.RawList{
+		"alice": {Tenant: "acme", Groups: []string{"member", "mod"}},
+		"bob":   {Tenant: "globex", Groups: []string{"member"}},
+	}
+	processor := func(p roller.GroupProvider) roller.Processor {
+		return roller.BasicProcessor{Provider: p}
+	}
+
+	proposed := roller.Group{ID: "acme/mod", Weight: 2, Permission: roller.Entry{Grant: []string{"kick", "ban"}}}
+	got, err := AnalyzeImpact(j, subjects, proposed, processor)
+	r.NoError(err)
+	r.Len(got, 1)
+	r.Equal("alice", got[0].Subject)
+	r.Equal(roller.ListDiff{Added: []string{"ban"}}, got[0].Diff)
+
+	got, err = AnalyzeImpact(j, subjects, roller.Group{ID: "member", Weight: 1, Permission: roller.Entry{Grant: []string{"chat", "emote"}}}, processor)
+	r.NoError(err)
+	r.Len(got, 2, "global groups should be resolved for every tenant")
+
+	_, err = AnalyzeImpact(j, map[string]roller.RawList{"eve": {Tenant: "globex", Groups: []string{"acme/mod"}}}, proposed, processor)
+	var e roller.MissingGroupError
+	r.ErrorAs(err, &e, "other tenants should not be reachable")
+}
@@ -1,16 +1,22 @@
 package provider
 
 import (
+	"bytes"
 	"encoding/json"
 	"github.com/Thunder33345/roller"
 	"io"
+	"sort"
 	"sync"
 )
 
 var _ GroupStorer = (*JSON)(nil)
+var _ BundleWalker = (*JSON)(nil)
+var _ Reviser = (*JSON)(nil)
+var _ Lister = (*JSON)(nil)
 
 type JSON struct {
-	groups []roller.Group
+	groups  []roller.Group
+	bundles []roller.Bundle
 	//file is where the data will be read and written to
 	//io.Closer is supported and will be closed when JSON.Close is called
 	file io.ReadWriter
@@ -23,7 +29,9 @@ type JSON struct {
 	//unsafeSave suppresses duplicate uid check when saving
 	//will still push the error down to next load
 	unsafeSave bool
-	m          sync.RWMutex
+	//gen is increased on every change to groups, used by Update to detect changes made while fn runs
+	gen uint64
+	m   sync.RWMutex
 }
 
 func NewJSON(file io.ReadWriter) (*JSON, error) {
@@ -58,13 +66,61 @@ func (j *JSON) AddGroup(group roller.Group) error {
 	if j.readOnly {
 		return ReadOnlyError{}
 	}
-	i, _ := j.findGroup(group.ID)
+	j.storeGroup(group)
+	return nil
+}
+
+func (j *JSON) CreateGroup(group roller.Group) (uint64, error) {
+	j.m.Lock()
+	defer j.m.Unlock()
+	if j.readOnly {
+		return 0, ReadOnlyError{}
+	}
+	if i, _ := j.findGroup(group.ID); i >= 0 {
+		return 0, NewGroupExistsError(group.ID)
+	}
+	return j.storeGroup(group), nil
+}
+
+func (j *JSON) UpdateGroup(group roller.Group) (uint64, error) {
+	j.m.Lock()
+	defer j.m.Unlock()
+	if j.readOnly {
+		return 0, ReadOnlyError{}
+	}
+	if i, _ := j.findGroup(group.ID); i < 0 {
+		return 0, NewGroupNotFoundError(group.ID)
+	}
+	return j.storeGroup(group), nil
+}
+
+func (j *JSON) CompareAndSwapGroup(group roller.Group, expectedRevision uint64) (uint64, error) {
+	j.m.Lock()
+	defer j.m.Unlock()
+	if j.readOnly {
+		return 0, ReadOnlyError{}
+	}
+	var cur uint64
+	if i, g := j.findGroup(group.ID); i >= 0 {
+		cur = g.Revision
+	}
+	if cur != expectedRevision {
+		return 0, NewConflictError(group.ID, expectedRevision, cur)
+	}
+	return j.storeGroup(group), nil
+}
+
+//storeGroup upserts group with the next revision, the caller must hold the lock
+func (j *JSON) storeGroup(group roller.Group) uint64 {
+	i, old := j.findGroup(group.ID)
+	group.Revision = old.Revision + 1
+	j.gen++
 	if i >= 0 {
 		j.groups[i] = group
-		return nil
+		return group.Revision
 	}
 	j.groups = append(j.groups, group)
-	return nil
+	return group.Revision
 }
 
 func (j *JSON) RemoveGroup(id string) error {
@@ -76,16 +132,56 @@ func (j *JSON) RemoveGroup(id string) error {
 	i, _ := j.findGroup(id)
 	if i >= 0 {
 		j.groups = append(j.groups[:i], j.groups[i+1:]...)
+		j.gen++
 		return nil
 	}
 	return NewGroupNotFoundError(id)
 }
 
-func (j *JSON) WalkGroup(f func(group roller.Group, last bool) (halt bool)) error {
+func (j *JSON) Bundle(name string) (roller.Bundle, error) {
 	j.m.RLock()
 	defer j.m.RUnlock()
-	for i, g := range j.groups {
-		halt := f(g, len(j.groups)-1 == i)
+	i, b := j.findBundle(name)
+	if i >= 0 {
+		return b, nil
+	}
+	return roller.Bundle{}, NewBundleNotFoundError(name)
+}
+
+func (j *JSON) AddBundle(bundle roller.Bundle) error {
+	j.m.Lock()
+	defer j.m.Unlock()
+	if j.readOnly {
+		return ReadOnlyError{}
+	}
+	i, _ := j.findBundle(bundle.Name)
+	if i >= 0 {
+		j.bundles[i] = bundle
+		return nil
+	}
+	j.bundles = append(j.bundles, bundle)
+	return nil
+}
+
+func (j *JSON) RemoveBundle(name string) error {
+	j.m.Lock()
+	defer j.m.Unlock()
+	if j.readOnly {
+		return ReadOnlyError{}
+	}
+	i, _ := j.findBundle(name)
+	if i >= 0 {
+		j.bundles = append(j.bundles[:i], j.bundles[i+1:]...)
+		return nil
+	}
+	return NewBundleNotFoundError(name)
+}
+
+//WalkGroup walks a snapshot of the groups, so the lock is not held while f runs
+func (j *JSON) WalkGroup(f func(group roller.Group, last bool) (halt bool)) error {
+	gs := j.snapshot()
+	for i, g := range gs {
+		halt := f(g, len(gs)-1 == i)
 		if halt {
 			return nil
 		}
@@ -93,6 +189,35 @@ func (j *JSON) WalkGroup(f func(group roller.Group, last bool) (halt bool)) erro
 	return nil
 }
 
+//WalkBundle walks a snapshot of the bundles, so the lock is not held while f runs
+func (j *JSON) WalkBundle(f func(bundle roller.Bundle, last bool) (halt bool)) error {
+	j.m.RLock()
+	bs := append([]roller.Bundle(nil), j.bundles...)
+	j.m.RUnlock()
+	for i, b := range bs {
+		if f(b, len(bs)-1 == i) {
+			return nil
+		}
+	}
+	return nil
+}
+
+//ListGroups pages through a snapshot of the groups, see Lister
+func (j *JSON) ListGroups(opts ListOptions) (GroupPage, error) {
+	gs := j.snapshot()
+	sort.Slice(gs, func(a, b int) bool {
+		return gs[a].ID < gs[b].ID
+	})
+	return listSorted(gs, opts), nil
+}
+
+//snapshot returns a copy of the groups
+func (j *JSON) snapshot() []roller.Group {
+	j.m.RLock()
+	defer j.m.RUnlock()
+	return append([]roller.Group(nil), j.groups...)
+}
+
 func (j *JSON) load() error {
 	dec := json.NewDecoder(j.file)
 	if !j.allowUnknown {
@@ -110,25 +235,59 @@ func (j *JSON) load() error {
 		return nil
 	}
 
-	var tg []roller.Group
-	if err := dec.Decode(&tg); err != nil {
+	var raw json.RawMessage
+	if err := dec.Decode(&raw); err != nil {
 		return err
 	}
-	if err := j.duplicateCheck(tg); err != nil {
+	raw, err := migrateJSON(raw, jsonMigrations)
+	if err != nil {
+		return err
+	}
+	var f jsonFile
+	if err := j.decode(raw, &f); err != nil {
 		return err
 	}
-	j.groups = tg
+	if err := j.duplicateCheck(f.Groups); err != nil {
+		return err
+	}
+	if err := j.duplicateBundleCheck(f.Bundles); err != nil {
+		return err
+	}
+	for i := range f.Groups {
+		if f.Groups[i].Revision == 0 {
+			f.Groups[i].Revision = 1
+		}
+	}
+	j.groups = f.Groups
+	j.bundles = f.Bundles
+	j.gen++
 	return nil
 }
 
+//jsonFile is the file layout of JSONVersion
+type jsonFile struct {
+	Version int             `json:"version"`
+	Groups  []roller.Group  `json:"groups"`
+	Bundles []roller.Bundle `json:"bundles,omitempty"`
+}
+
+//decode decodes data into v, following allowUnknown
+func (j *JSON) decode(data []byte, v interface{}) error {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	if !j.allowUnknown {
+		dec.DisallowUnknownFields()
+	}
+	return dec.Decode(v)
+}
+
 func (j *JSON) Reload() error {
 	j.m.Lock()
 	defer j.m.Unlock()
-	c := j.groups
-	j.groups = nil
+	c, cb := j.groups, j.bundles
+	j.groups, j.bundles = nil, nil
 	err := j.load()
 	if err != nil {
-		j.groups = c
+		j.groups, j.bundles = c, cb
 		return err
 	}
 	return nil
@@ -137,12 +296,20 @@ func (j *JSON) Reload() error {
 func (j *JSON) Save() error {
 	j.m.RLock()
 	defer j.m.RUnlock()
+	return j.save()
+}
+
+//save writes out the current state, the caller must hold the lock
+func (j *JSON) save() error {
 	if j.readOnly {
 		return ReadOnlyError{}
 	}
 	if err := j.duplicateCheck(j.groups); err != nil && !j.unsafeSave {
 		return err
 	}
+	if err := j.duplicateBundleCheck(j.bundles); err != nil && !j.unsafeSave {
+		return err
+	}
 
 	switch t := j.file.(type) {
 	case truncateSeeker:
@@ -160,7 +327,7 @@ func (j *JSON) Save() error {
 	enc.SetEscapeHTML(false)
 	enc.SetIndent("", j.indent)
 
-	if err := enc.Encode(j.groups); err != nil {
+	if err := enc.Encode(jsonFile{Version: JSONVersion, Groups: j.groups, Bundles: j.bundles}); err != nil {
 		return err
 	}
 	return nil
@@ -168,6 +335,8 @@ func (j *JSON) Save() error {
 
 func (j *JSON) Close() error {
 	j.groups = nil
+	j.gen++
+	j.bundles = nil
 	if c, ok := j.file.(io.Closer); ok {
 		return c.Close()
 	}
@@ -183,6 +352,26 @@ func (j *JSON) findGroup(id string) (int, roller.Group) {
 	return -1, roller.Group{}
 }
 
+func (j *JSON) findBundle(name string) (int, roller.Bundle) {
+	for i, b := range j.bundles {
+		if b.Name == name {
+			return i, b
+		}
+	}
+	return -1, roller.Bundle{}
+}
+
+func (j *JSON) duplicateBundleCheck(bundles []roller.Bundle) error {
+	found := make(map[string]struct{}, len(bundles))
+	for _, b := range bundles {
+		if _, exist := found[b.Name]; exist {
+			return NewDuplicateBundleNameError(b.Name)
+		}
+		found[b.Name] = struct{}{}
+	}
+	return nil
+}
+
 func (j *JSON) duplicateCheck(groups []roller.Group) error {
 	found := make(map[string]int, len(groups))
 	for i, g := range groups {
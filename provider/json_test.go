@@ -70,7 +70,7 @@ func TestNewJSON(t *testing.T) {
 		}, {
 			name: "load something",
 			file: bytes.NewBufferString(sample),
-			want: []roller.Group{{Name: "test", ID: "100"}, {Name: "test2", ID: "101"}},
+			want: []roller.Group{{Name: "test", ID: "100", Revision: 1}, {Name: "test2", ID: "101", Revision: 1}},
 		}, {
 			name:             "load duplicated",
 			file:             bytes.NewBufferString(duplicatedSample),
@@ -120,14 +120,14 @@ func TestJSON_Save(t *testing.T) {
 			groups:  []roller.Group{{Name: "test", ID: "100"}, {Name: "test2", ID: "101"}},
 			file:    &bytes.Buffer{},
 			wantErr: false,
-			want: `[{"name":"test","ref_name":"","id":"100","weight":0,"permission":{}},{"name":"test2","ref_name":"","id":"101","weight":0,"permission":{}}]
+			want: `{"version":1,"groups":[{"name":"test","ref_name":"","id":"100","weight":0,"permission":{}},{"name":"test2","ref_name":"","id":"101","weight":0,"permission":{}}]}
 `,
 		}, {
 			name:    "successful prefilled buffer",
 			groups:  []roller.Group{{Name: "test", ID: "100"}},
 			file:    bytes.NewBufferString("[1,2,3,4,5,6,7,8,9,0]"),
 			wantErr: false,
-			want: `[{"name":"test","ref_name":"","id":"100","weight":0,"permission":{}}]
+			want: `{"version":1,"groups":[{"name":"test","ref_name":"","id":"100","weight":0,"permission":{}}]}
 `,
 		}, {
 			name:        "duplicated",
@@ -189,7 +189,7 @@ func TestJSON_Reload(t *testing.T) {
 			groups:     []roller.Group{{Name: "1", ID: "1"}, {Name: "2", ID: "2"}},
 			file:       bytes.NewBufferString(`[{"Name": "test","ID": "100"},{"Name": "test2","ID": "101"}]`),
 			wantErr:    false,
-			wantGroups: []roller.Group{{Name: "test", ID: "100"}, {Name: "test2", ID: "101"}},
+			wantGroups: []roller.Group{{Name: "test", ID: "100", Revision: 1}, {Name: "test2", ID: "101", Revision: 1}},
 		}, {
 			name:       "reload to nil",
 			groups:     []roller.Group{{Name: "1", ID: "1"}, {Name: "2", ID: "2"}},
@@ -273,12 +273,12 @@ func TestJSON_AddGroup(t *testing.T) {
 			name:      "simple add",
 			groups:    []roller.Group{{Name: "foo", ID: "1"}, {Name: "bar", ID: "2"}, {Name: "baz", ID: "3"}, {Name: "faz", ID: "5"}},
 			argGroup:  roller.Group{Name: "far", ID: "4"},
-			wantState: []roller.Group{{Name: "foo", ID: "1"}, {Name: "bar", ID: "2"}, {Name: "baz", ID: "3"}, {Name: "faz", ID: "5"}, {Name: "far", ID: "4"}},
+			wantState: []roller.Group{{Name: "foo", ID: "1"}, {Name: "bar", ID: "2"}, {Name: "baz", ID: "3"}, {Name: "faz", ID: "5"}, {Name: "far", ID: "4", Revision: 1}},
 		}, {
 			name:      "add update",
-			groups:    []roller.Group{{Name: "foo", ID: "1"}, {Name: "bar", ID: "2"}, {Name: "baz", ID: "3"}},
-			argGroup:  roller.Group{Name: "far", ID: "3"},
-			wantState: []roller.Group{{Name: "foo", ID: "1"}, {Name: "bar", ID: "2"}, {Name: "far", ID: "3"}},
+			groups:    []roller.Group{{Name: "foo", ID: "1"}, {Name: "bar", ID: "2"}, {Name: "baz", ID: "3", Revision: 4}},
+			argGroup:  roller.Group{Name: "far", ID: "3", Revision: 1},
+			wantState: []roller.Group{{Name: "foo", ID: "1"}, {Name: "bar", ID: "2"}, {Name: "far", ID: "3", Revision: 5}},
 		}, {
 			name:      "readonly locked",
 			groups:    []roller.Group{{Name: "foo", ID: "1"}, {Name: "bar", ID: "2"}, {Name: "baz", ID: "3"}},
@@ -538,3 +538,125 @@ func TestJSON_findGroup(t *testing.T) {
 		})
 	}
 }
+
+func TestJSON_Bundle(t *testing.T) {
+	r := require.New(t)
+	file := &bytes.Buffer{}
+	j, err := NewJSONWithOptions(file, false, false, "", false)
+	r.NoError(err)
+	r.NoError(j.AddGroup(roller.Group{ID: "100", Permission: roller.Entry{Include: []string{"chat"}}}))
+
+	_, err = j.Bundle("chat")
+	var nf BundleNotFoundError
+	r.ErrorAs(err, &nf)
+	r.Equal("chat", nf.Name())
+
+	r.NoError(j.AddBundle(roller.Bundle{Name: "chat", Grant: []string{"chat.send"}}))
+	r.NoError(j.AddBundle(roller.Bundle{Name: "chat", Grant: []string{"chat.send", "chat.read"}}))
+	r.NoError(j.AddBundle(roller.Bundle{Name: "old"}))
+	r.NoError(j.RemoveBundle("old"))
+	r.ErrorAs(j.RemoveBundle("old"), &nf)
+
+	b, err := j.Bundle("chat")
+	r.NoError(err)
+	r.Equal([]string{"chat.send", "chat.read"}, b.Grant)
+
+	l, err := roller.BasicProcessor{Provider: j}.Process(roller.RawList{Groups: []string{"100"}})
+	r.NoError(err)
+	r.Equal([]string{"chat.send", "chat.read"}, l.Permission)
+
+	r.NoError(j.Save())
+	r.Equal(`{"version":1,"groups":[{"name":"","ref_name":"","id":"100","weight":0,"permission":{"include":["chat"]},"revision":1}],"bundles":[{"name":"chat","grant":["chat.send","chat.read"]}]}
+`, file.String())
+	r.NoError(j.Reload())
+	b, err = j.Bundle("chat")
+	r.NoError(err)
+	r.Equal("chat", b.Name)
+	_, err = j.Group("100")
+	r.NoError(err)
+
+	r.NoError(j.RemoveBundle("chat"))
+	r.NoError(j.Save())
+	r.Equal(`{"version":1,"groups":[{"name":"","ref_name":"","id":"100","weight":0,"permission":{"include":["chat"]},"revision":1}]}
+`, file.String())
+
+	_, err = NewJSON(bytes.NewBufferString(`{"groups":[],"bundles":[{"name":"a"},{"name":"a"}]}`))
+	var de DuplicateBundleNameError
+	r.ErrorAs(err, &de)
+	r.Equal("a", de.Name())
+
+	_, err = NewJSON(bytes.NewBufferString(`{"groups":[],"unknown":1}`))
+	r.Error(err)
+
+	ro, err := NewJSONWithOptions(&bytes.Buffer{}, false, true, "", false)
+	r.NoError(err)
+	var re ReadOnlyError
+	r.ErrorAs(ro.AddBundle(roller.Bundle{Name: "a"}), &re)
+	r.ErrorAs(ro.RemoveBundle("a"), &re)
+}
+
+func TestJSON_Revisions(t *testing.T) {
+	r := require.New(t)
+	j, err := NewJSON(bytes.NewBufferString(`[{"id":"100"},{"id":"101","revision":7}]`))
+	r.NoError(err)
+	g, err := j.Group("100")
+	r.NoError(err)
+	r.Equal(uint64(1), g.Revision, "groups without revision should start from 1")
+
+	rev, err := j.CreateGroup(roller.Group{ID: "102", Revision: 9})
+	r.NoError(err)
+	r.Equal(uint64(1), rev)
+	_, err = j.CreateGroup(roller.Group{ID: "102"})
+	var ee GroupExistsError
+	r.ErrorAs(err, &ee)
+	r.Equal("102", ee.ID())
+
+	rev, err = j.UpdateGroup(roller.Group{ID: "101", Name: "updated"})
+	r.NoError(err)
+	r.Equal(uint64(8), rev)
+	_, err = j.UpdateGroup(roller.Group{ID: "103"})
+	var nf GroupNotFoundError
+	r.ErrorAs(err, &nf)
+
+	//two editors read revision 8, the second one to write conflicts
+	rev, err = j.CompareAndSwapGroup(roller.Group{ID: "101", Name: "first"}, 8)
+	r.NoError(err)
+	r.Equal(uint64(9), rev)
+	_, err = j.CompareAndSwapGroup(roller.Group{ID: "101", Name: "second"}, 8)
+	var ce ConflictError
+	r.ErrorAs(err, &ce)
+	r.Equal("101", ce.ID())
+	r.Equal(uint64(8), ce.Expected())
+	r.Equal(uint64(9), ce.Actual())
+	r.Equal(`group ID "101" revision conflict: expected revision 8, got 9`, ce.Error())
+	g, err = j.Group("101")
+	r.NoError(err)
+	r.Equal("first", g.Name)
+
+	rev, err = j.CompareAndSwapGroup(roller.Group{ID: "103"}, 0)
+	r.NoError(err)
+	r.Equal(uint64(1), rev)
+	_, err = j.CompareAndSwapGroup(roller.Group{ID: "103"}, 0)
+	r.ErrorAs(err, &ce)
+	r.Equal(uint64(1), ce.Actual())
+	_, err = j.CompareAndSwapGroup(roller.Group{ID: "104"}, 1)
+	r.ErrorAs(err, &ce)
+	r.Equal(uint64(0), ce.Actual())
+
+	r.NoError(j.AddGroup(roller.Group{ID: "103"}))
+	r.NoError(j.Update(func(tx GroupTx) error {
+		return tx.AddGroup(roller.Group{ID: "103"})
+	}))
+	g, err = j.Group("103")
+	r.NoError(err)
+	r.Equal(uint64(3), g.Revision)
+
+	j.readOnly = true
+	var ro ReadOnlyError
+	_, err = j.CreateGroup(roller.Group{ID: "105"})
+	r.ErrorAs(err, &ro)
+	_, err = j.UpdateGroup(roller.Group{ID: "100"})
+	r.ErrorAs(err, &ro)
+	_, err = j.CompareAndSwapGroup(roller.Group{ID: "100"}, 1)
+	r.ErrorAs(err, &ro)
+}
@@ -0,0 +1,172 @@
+package provider
+
+import (
+	"github.com/Thunder33345/roller"
+)
+
+var _ Transactor = (*JSON)(nil)
+
+//txAttempts is how many times JSON.Update runs fn before giving up on conflicts
+const txAttempts = 3
+
+//Update runs fn with a transaction on a snapshot of the groups, see Transactor
+//the lock is not held while fn runs, so JSON stays usable by others and inside fn
+//if the groups were changed after the snapshot was taken, fn is run again on a fresh snapshot up to txAttempts times
+//returns TxConflictError without committing if every attempt conflicted
+func (j *JSON) Update(fn func(tx GroupTx) error) error {
+	for i := 0; i < txAttempts; i++ {
+		err := j.update(fn)
+		if _, ok := err.(TxConflictError); !ok {
+			return err
+		}
+	}
+	return TxConflictError{}
+}
+
+//update runs fn once, returns TxConflictError if the groups were changed while fn ran
+func (j *JSON) update(fn func(tx GroupTx) error) error {
+	j.m.RLock()
+	tx := &jsonTx{j: j, base: append([]roller.Group(nil), j.groups...), gen: j.gen, staged: make(map[string]*roller.Group)}
+	j.m.RUnlock()
+	err := fn(tx)
+	tx.done = true
+	if err != nil {
+		return err
+	}
+	if len(tx.staged) == 0 && !tx.save {
+		return nil
+	}
+
+	j.m.Lock()
+	defer j.m.Unlock()
+	if j.gen != tx.gen {
+		return TxConflictError{}
+	}
+	old := j.groups
+	j.groups = tx.view()
+	if tx.save {
+		if err := j.save(); err != nil {
+			j.groups = old
+			return err
+		}
+	}
+	j.gen++
+	return nil
+}
+
+//jsonTx is the GroupTx of JSON, changes are staged on top of a snapshot of JSON.groups until committed
+type jsonTx struct {
+	j *JSON
+	//base is the snapshot of JSON.groups, taken when JSON.gen was gen
+	base []roller.Group
+	gen  uint64
+	//staged are changed groups keyed by ID, a nil group is removed
+	staged map[string]*roller.Group
+	//added are IDs of staged groups in the order they are first staged, used to place new groups
+	added []string
+	save  bool
+	done  bool
+}
+
+func (t *jsonTx) Group(id string) (roller.Group, error) {
+	if t.done {
+		return roller.Group{}, TxDoneError{}
+	}
+	if g, ok := t.staged[id]; ok {
+		if g == nil {
+			return roller.Group{}, NewGroupNotFoundError(id)
+		}
+		return *g, nil
+	}
+	if g, ok := t.find(id); ok {
+		return g, nil
+	}
+	return roller.Group{}, NewGroupNotFoundError(id)
+}
+
+func (t *jsonTx) AddGroup(group roller.Group) error {
+	if t.done {
+		return TxDoneError{}
+	}
+	if t.j.readOnly {
+		return ReadOnlyError{}
+	}
+	if _, ok := t.staged[group.ID]; !ok {
+		t.added = append(t.added, group.ID)
+	}
+	old, _ := t.find(group.ID)
+	group.Revision = old.Revision + 1
+	t.staged[group.ID] = &group
+	return nil
+}
+
+func (t *jsonTx) RemoveGroup(id string) error {
+	if t.done {
+		return TxDoneError{}
+	}
+	if t.j.readOnly {
+		return ReadOnlyError{}
+	}
+	if _, err := t.Group(id); err != nil {
+		return err
+	}
+	if _, ok := t.staged[id]; !ok {
+		t.added = append(t.added, id)
+	}
+	t.staged[id] = nil
+	return nil
+}
+
+func (t *jsonTx) WalkGroup(f func(group roller.Group, last bool) (halt bool)) error {
+	if t.done {
+		return TxDoneError{}
+	}
+	gs := t.view()
+	for i, g := range gs {
+		if f(g, len(gs)-1 == i) {
+			return nil
+		}
+	}
+	return nil
+}
+
+func (t *jsonTx) SaveOnCommit() {
+	t.save = true
+}
+
+//view returns a new slice of groups with staged changes applied
+//existing groups keep their position, new groups are appended in the order they are staged
+func (t *jsonTx) view() []roller.Group {
+	gs := make([]roller.Group, 0, len(t.base)+len(t.added))
+	seen := make(map[string]struct{}, len(t.staged))
+	for _, g := range t.base {
+		s, ok := t.staged[g.ID]
+		if !ok {
+			gs = append(gs, g)
+			continue
+		}
+		seen[g.ID] = struct{}{}
+		if s != nil {
+			gs = append(gs, *s)
+		}
+	}
+	for _, id := range t.added {
+		if _, ok := seen[id]; ok {
+			continue
+		}
+		if s := t.staged[id]; s != nil {
+			gs = append(gs, *s)
+		}
+	}
+	return gs
+}
+
+//find returns the group of the snapshot, ignoring staged changes
+func (t *jsonTx) find(id string) (roller.Group, bool) {
+	for _, g := range t.base {
+		if g.ID == id {
+			return g, true
+		}
+	}
+	return roller.Group{}, false
+}
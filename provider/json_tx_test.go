@@ -0,0 +1,189 @@
+package provider
+
+import (
+	"bytes"
+	"errors"
+	"github.com/Thunder33345/roller"
+	"github.com/stretchr/testify/require"
+	"sync"
+	"testing"
+)
+
+func txIDs(r *require.Assertions, w interface {
+	WalkGroup(func(group roller.Group, last bool) (halt bool)) error
+}) []string {
+	var ids []string
+	r.NoError(w.WalkGroup(func(group roller.Group, last bool) bool {
+		ids = append(ids, group.ID)
+		return false
+	}))
+	return ids
+}
+
+func TestJSON_Update(t *testing.T) {
+	r := require.New(t)
+	file := bytes.NewBufferString(`[{"id":"100","name":"a"},{"id":"101","name":"b"},{"id":"102","name":"c"}]`)
+	j, err := NewJSON(file)
+	r.NoError(err)
+
+	var leaked GroupTx
+	r.NoError(j.Update(func(tx GroupTx) error {
+		leaked = tx
+		r.NoError(tx.AddGroup(roller.Group{ID: "103", Name: "d"}))
+		r.NoError(tx.AddGroup(roller.Group{ID: "100", Name: "a2"}))
+		r.NoError(tx.RemoveGroup("101"))
+		var nf GroupNotFoundError
+		r.ErrorAs(tx.RemoveGroup("101"), &nf)
+		_, err := tx.Group("101")
+		r.ErrorAs(err, &nf)
+		g, err := tx.Group("100")
+		r.NoError(err)
+		r.Equal("a2", g.Name)
+		r.Equal([]string{"100", "102", "103"}, txIDs(r, tx))
+
+		r.NoError(tx.AddGroup(roller.Group{ID: "104"}))
+		r.NoError(tx.RemoveGroup("104"))
+		return nil
+	}))
+	r.Equal([]string{"100", "102", "103"}, txIDs(r, j))
+	g, err := j.Group("100")
+	r.NoError(err)
+	r.Equal("a2", g.Name)
+	r.Zero(file.Len(), "should not save by default")
+
+	var de TxDoneError
+	r.ErrorAs(leaked.AddGroup(roller.Group{ID: "105"}), &de)
+	_, err = leaked.Group("100")
+	r.ErrorAs(err, &de)
+
+	fail := errors.New("fail")
+	err = j.Update(func(tx GroupTx) error {
+		r.NoError(tx.RemoveGroup("100"))
+		r.NoError(tx.AddGroup(roller.Group{ID: "106"}))
+		return fail
+	})
+	r.ErrorIs(err, fail)
+	r.Equal([]string{"100", "102", "103"}, txIDs(r, j), "should be rolled back")
+
+	r.NoError(j.Update(func(tx GroupTx) error {
+		r.NoError(tx.RemoveGroup("102"))
+		tx.SaveOnCommit()
+		return nil
+	}))
+	r.NoError(j.Reload())
+	r.Equal([]string{"100", "103"}, txIDs(r, j))
+}
+
+func TestJSON_UpdateSaveFailed(t *testing.T) {
+	r := require.New(t)
+	j, err := NewJSON(&bytes.Buffer{})
+	r.NoError(err)
+	r.NoError(j.AddGroup(roller.Group{ID: "100"}))
+
+	err = j.Update(func(tx GroupTx) error {
+		r.NoError(tx.AddGroup(roller.Group{ID: "101"}))
+		r.NoError(tx.AddGroup(roller.Group{ID: "100", Name: "changed"}))
+		tx.SaveOnCommit()
+		j.readOnly = true
+		return nil
+	})
+	var ro ReadOnlyError
+	r.ErrorAs(err, &ro)
+	j.readOnly = false
+	r.Equal([]string{"100"}, txIDs(r, j), "failed save should roll back")
+	g, err := j.Group("100")
+	r.NoError(err)
+	r.Equal("", g.Name)
+
+	j.readOnly = true
+	r.NoError(j.Update(func(tx GroupTx) error {
+		r.ErrorAs(tx.AddGroup(roller.Group{ID: "101"}), &ro)
+		r.ErrorAs(tx.RemoveGroup("100"), &ro)
+		return nil
+	}))
+}
+
+func TestJSON_UpdateAtomic(t *testing.T) {
+	r := require.New(t)
+	j, err := NewJSON(&bytes.Buffer{})
+	r.NoError(err)
+	for _, id := range []string{"1", "2", "3", "4"} {
+		r.NoError(j.AddGroup(roller.Group{ID: id, Name: "old"}))
+	}
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 100; i++ {
+			names := make(map[string]struct{})
+			_ = j.WalkGroup(func(group roller.Group, last bool) bool {
+				names[group.Name] = struct{}{}
+				return false
+			})
+			if len(names) != 1 {
+				t.Errorf("saw a partially applied transaction: %v", names)
+				return
+			}
+		}
+	}()
+	for i := 0; i < 100; i++ {
+		name := "old"
+		if i%2 == 0 {
+			name = "new"
+		}
+		r.NoError(j.Update(func(tx GroupTx) error {
+			return tx.WalkGroup(func(group roller.Group, last bool) bool {
+				group.Name = name
+				return tx.AddGroup(group) != nil
+			})
+		}))
+	}
+	wg.Wait()
+}
+
+func TestJSON_UpdateConflict(t *testing.T) {
+	r := require.New(t)
+	j, err := NewJSON(&bytes.Buffer{})
+	r.NoError(err)
+	r.NoError(j.AddGroup(roller.Group{ID: "100"}))
+
+	attempts := 0
+	err = j.Update(func(tx GroupTx) error {
+		attempts++
+		_, err := j.Group("100")
+		r.NoError(err, "JSON should be usable while fn runs")
+		r.NoError(tx.AddGroup(roller.Group{ID: "101"}))
+		return j.AddGroup(roller.Group{ID: "102"})
+	})
+	r.ErrorAs(err, &TxConflictError{})
+	r.Equal(txAttempts, attempts)
+	r.Equal([]string{"100", "102"}, txIDs(r, j), "conflicting transaction should be discarded")
+
+	attempts = 0
+	r.NoError(j.Update(func(tx GroupTx) error {
+		attempts++
+		if attempts == 1 {
+			r.NoError(j.AddGroup(roller.Group{ID: "104"}))
+		}
+		_, err := tx.Group("104")
+		if attempts == 2 {
+			r.NoError(err, "retry should see a fresh snapshot")
+		}
+		return tx.RemoveGroup("102")
+	}))
+	r.Equal(2, attempts)
+	r.Equal([]string{"100", "104"}, txIDs(r, j))
+
+	r.NoError(j.Update(func(tx GroupTx) error {
+		r.NoError(j.AddGroup(roller.Group{ID: "103"}))
+		_, err := tx.Group("103")
+		r.ErrorAs(err, &GroupNotFoundError{}, "tx should only see its snapshot")
+		return nil
+	}), "transaction without changes should not conflict")
+
+	r.NoError(j.Update(func(tx GroupTx) error {
+		return tx.RemoveGroup("103")
+	}))
+	r.Equal([]string{"100", "104"}, txIDs(r, j))
+}
@@ -0,0 +1,252 @@
+package provider
+
+import (
+	"errors"
+	"github.com/Thunder33345/roller"
+)
+
+var _ Walker = (*Layered)(nil)
+var _ BundleWalker = (*Layered)(nil)
+
+//Layered stacks multiple providers, where the first layer that has a group or bundle wins
+//useful for shipping default groups that are overridden or added to by a local provider
+type Layered struct {
+	layers []roller.GroupProvider
+	//writable is the index of the layer AddGroup, RemoveGroup, AddBundle and RemoveBundle go to, -1 if none
+	writable int
+	//mergeFlags makes Flags of the same group from lower layers merged into the winning group
+	//flags of higher layers win over the same named flags of lower layers
+	mergeFlags bool
+}
+
+//NewLayered creates a Layered out of layers ordered from the highest to the lowest precedent
+//writable is the index of the layer that will be written to, it must be a GroupStorer, or -1 to be read only
+func NewLayered(writable int, layers ...roller.GroupProvider) (*Layered, error) {
+	return NewLayeredWithOptions(writable, false, layers...)
+}
+
+func NewLayeredWithOptions(writable int, mergeFlags bool, layers ...roller.GroupProvider) (*Layered, error) {
+	if writable >= len(layers) || writable < -1 {
+		return nil, NewLayerError(writable, "out of range")
+	}
+	if writable >= 0 {
+		if _, ok := layers[writable].(GroupStorer); !ok {
+			return nil, NewLayerError(writable, "not a GroupStorer")
+		}
+	}
+	return &Layered{layers: layers, writable: writable, mergeFlags: mergeFlags}, nil
+}
+
+//Group returns the group from the first layer that has it
+//a layer error other than GroupNotFoundError is returned as is
+func (l *Layered) Group(id string) (roller.Group, error) {
+	var found bool
+	var g roller.Group
+	for _, p := range l.layers {
+		lg, err := p.Group(id)
+		if err != nil {
+			var nf GroupNotFoundError
+			if errors.As(err, &nf) {
+				continue
+			}
+			return roller.Group{}, err
+		}
+		if !found {
+			g, found = lg, true
+			if !l.mergeFlags {
+				break
+			}
+			continue
+		}
+		g = l.merge(g, lg)
+	}
+	if !found {
+		return roller.Group{}, NewGroupNotFoundError(id)
+	}
+	return g, nil
+}
+
+//AddGroup stores the group into the writable layer
+func (l *Layered) AddGroup(group roller.Group) error {
+	s, err := l.writer()
+	if err != nil {
+		return err
+	}
+	return s.AddGroup(group)
+}
+
+//RemoveGroup removes the group from the writable layer
+//the group will still be provided if any lower layer has it
+func (l *Layered) RemoveGroup(id string) error {
+	s, err := l.writer()
+	if err != nil {
+		return err
+	}
+	return s.RemoveGroup(id)
+}
+
+//WalkGroup walks the groups of every layer that's a Walker, other layers are skipped, so are layers returning UnsupportedError
+//IDs are walked in layer order, every group is walked once as it's returned by Group, so layers that aren't a Walker still take precedent
+func (l *Layered) WalkGroup(f func(group roller.Group, last bool) (halt bool)) error {
+	var ids []string
+	seen := make(map[string]struct{})
+	for _, p := range l.layers {
+		w, ok := p.(Walker)
+		if !ok {
+			continue
+		}
+		if err := w.WalkGroup(func(group roller.Group, last bool) bool {
+			if _, ok := seen[group.ID]; !ok {
+				seen[group.ID] = struct{}{}
+				ids = append(ids, group.ID)
+			}
+			return false
+		}); err != nil {
+			var ue UnsupportedError
+			if errors.As(err, &ue) {
+				continue
+			}
+			return err
+		}
+	}
+	gs := make([]roller.Group, 0, len(ids))
+	for _, id := range ids {
+		g, err := l.Group(id)
+		if err != nil {
+			var nf GroupNotFoundError
+			if errors.As(err, &nf) {
+				continue
+			}
+			return err
+		}
+		gs = append(gs, g)
+	}
+	for i, g := range gs {
+		if f(g, len(gs)-1 == i) {
+			return nil
+		}
+	}
+	return nil
+}
+
+//Bundle returns the bundle from the first layer that's a roller.BundleProvider and has it
+//a layer error other than BundleNotFoundError is returned as is
+func (l *Layered) Bundle(name string) (roller.Bundle, error) {
+	for _, p := range l.layers {
+		bp, ok := p.(roller.BundleProvider)
+		if !ok {
+			continue
+		}
+		b, err := bp.Bundle(name)
+		if err != nil {
+			var nf BundleNotFoundError
+			if errors.As(err, &nf) {
+				continue
+			}
+			return roller.Bundle{}, err
+		}
+		return b, nil
+	}
+	return roller.Bundle{}, NewBundleNotFoundError(name)
+}
+
+//AddBundle stores the bundle into the writable layer
+//returns UnsupportedError if the writable layer is not a BundleStorer
+func (l *Layered) AddBundle(bundle roller.Bundle) error {
+	s, err := l.bundleWriter("AddBundle")
+	if err != nil {
+		return err
+	}
+	return s.AddBundle(bundle)
+}
+
+//RemoveBundle removes the bundle from the writable layer
+//the bundle will still be provided if any lower layer has it
+//returns UnsupportedError if the writable layer is not a BundleStorer
+func (l *Layered) RemoveBundle(name string) error {
+	s, err := l.bundleWriter("RemoveBundle")
+	if err != nil {
+		return err
+	}
+	return s.RemoveBundle(name)
+}
+
+//WalkBundle walks the bundles of every layer that's a BundleWalker, other layers are skipped, so are layers returning UnsupportedError
+//names are walked in layer order, every bundle is walked once as it's returned by Bundle, so the first layer that has it wins
+func (l *Layered) WalkBundle(f func(bundle roller.Bundle, last bool) (halt bool)) error {
+	var names []string
+	seen := make(map[string]struct{})
+	for _, p := range l.layers {
+		w, ok := p.(BundleWalker)
+		if !ok {
+			continue
+		}
+		if err := w.WalkBundle(func(bundle roller.Bundle, last bool) bool {
+			if _, ok := seen[bundle.Name]; !ok {
+				seen[bundle.Name] = struct{}{}
+				names = append(names, bundle.Name)
+			}
+			return false
+		}); err != nil {
+			var ue UnsupportedError
+			if errors.As(err, &ue) {
+				continue
+			}
+			return err
+		}
+	}
+	bs := make([]roller.Bundle, 0, len(names))
+	for _, n := range names {
+		b, err := l.Bundle(n)
+		if err != nil {
+			var nf BundleNotFoundError
+			if errors.As(err, &nf) {
+				continue
+			}
+			return err
+		}
+		bs = append(bs, b)
+	}
+	for i, b := range bs {
+		if f(b, len(bs)-1 == i) {
+			return nil
+		}
+	}
+	return nil
+}
+
+//merge returns a copy of high with flags of low it doesn't have added
+func (l *Layered) merge(high roller.Group, low roller.Group) roller.Group {
+	if len(low.Flags) == 0 {
+		return high
+	}
+	flags := make(map[string]roller.FlagEntry, len(high.Flags)+len(low.Flags))
+	for k, v := range low.Flags {
+		flags[k] = v
+	}
+	for k, v := range high.Flags {
+		flags[k] = v
+	}
+	high.Flags = flags
+	return high
+}
+
+//writer returns the writable layer
+func (l *Layered) writer() (GroupStorer, error) {
+	if l.writable < 0 {
+		return nil, ReadOnlyError{}
+	}
+	return l.layers[l.writable].(GroupStorer), nil
+}
+
+//bundleWriter returns the writable layer as a BundleStorer, op is the operation reported by UnsupportedError
+func (l *Layered) bundleWriter(op string) (BundleStorer, error) {
+	if l.writable < 0 {
+		return nil, ReadOnlyError{}
+	}
+	s, ok := l.layers[l.writable].(BundleStorer)
+	if !ok {
+		return nil, NewUnsupportedError(op)
+	}
+	return s, nil
+}
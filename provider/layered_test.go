@@ -0,0 +1,198 @@
+package provider
+
+import (
+	"bytes"
+	"errors"
+	"github.com/Thunder33345/roller"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+//providerOnly hides every interface other than roller.GroupProvider
+type providerOnly struct {
+	roller.GroupProvider
+}
+
+type brokenProvider struct{}
+
+func (brokenProvider) Group(string) (roller.Group, error) {
+	return roller.Group{}, errors.New("backend down")
+}
+
+func layeredFixture(r *require.Assertions) (local *JSON, defaults *JSON) {
+	local, err := NewJSON(&bytes.Buffer{})
+	r.NoError(err)
+	r.NoError(local.AddGroup(roller.Group{ID: "mod", Name: "Local Mod", Flags: map[string]roller.FlagEntry{
+		"night": {Weight: 2},
+	}}))
+	r.NoError(local.AddGroup(roller.Group{ID: "custom"}))
+
+	defaults, err = NewJSON(&bytes.Buffer{})
+	r.NoError(err)
+	r.NoError(defaults.AddGroup(roller.Group{ID: "member"}))
+	r.NoError(defaults.AddGroup(roller.Group{ID: "mod", Name: "Default Mod", Flags: map[string]roller.FlagEntry{
+		"night": {Weight: 1},
+		"event": {Weight: 1},
+	}}))
+	return local, defaults
+}
+
+func TestLayered(t *testing.T) {
+	r := require.New(t)
+	local, defaults := layeredFixture(r)
+	l, err := NewLayered(0, local, defaults)
+	r.NoError(err)
+
+	g, err := l.Group("mod")
+	r.NoError(err)
+	r.Equal("Local Mod", g.Name)
+	r.Len(g.Flags, 1)
+	g, err = l.Group("member")
+	r.NoError(err)
+	r.Equal("member", g.ID)
+	_, err = l.Group("missing")
+	var nf GroupNotFoundError
+	r.ErrorAs(err, &nf)
+
+	r.Equal([]string{"mod", "custom", "member"}, txIDs(r, l))
+
+	r.NoError(l.AddGroup(roller.Group{ID: "member", Name: "Local Member"}))
+	g, err = l.Group("member")
+	r.NoError(err)
+	r.Equal("Local Member", g.Name)
+	_, err = local.Group("member")
+	r.NoError(err, "writes should go to the writable layer")
+
+	r.NoError(l.RemoveGroup("member"))
+	g, err = l.Group("member")
+	r.NoError(err)
+	r.Equal("", g.Name, "lower layer should show through after removal")
+
+	l, err = NewLayered(-1, providerOnly{local}, defaults)
+	r.NoError(err)
+	var ro ReadOnlyError
+	r.ErrorAs(l.AddGroup(roller.Group{ID: "x"}), &ro)
+	r.ErrorAs(l.RemoveGroup("x"), &ro)
+	r.Equal([]string{"member", "mod"}, txIDs(r, l), "non walker layers should be skipped")
+
+	l, err = NewLayered(-1, brokenProvider{}, defaults)
+	r.NoError(err)
+	_, err = l.Group("member")
+	r.EqualError(err, "backend down")
+}
+
+func TestLayered_MergeFlags(t *testing.T) {
+	r := require.New(t)
+	local, defaults := layeredFixture(r)
+	l, err := NewLayeredWithOptions(0, true, local, defaults)
+	r.NoError(err)
+
+	want := roller.Group{ID: "mod", Name: "Local Mod", Revision: 1, Flags: map[string]roller.FlagEntry{
+		"night": {Weight: 2},
+		"event": {Weight: 1},
+	}}
+	g, err := l.Group("mod")
+	r.NoError(err)
+	r.Equal(want, g)
+
+	var walked roller.Group
+	r.NoError(l.WalkGroup(func(group roller.Group, last bool) bool {
+		walked = group
+		return group.ID == "mod"
+	}))
+	r.Equal(want, walked)
+
+	g, err = local.Group("mod")
+	r.NoError(err)
+	r.Len(g.Flags, 1, "layers should not be altered")
+}
+
+func TestNewLayered(t *testing.T) {
+	r := require.New(t)
+	_, defaults := layeredFixture(r)
+	var le LayerError
+	_, err := NewLayered(1, defaults)
+	r.ErrorAs(err, &le)
+	r.Equal("invalid writable layer 1: out of range", le.Error())
+	_, err = NewLayered(-2, defaults)
+	r.ErrorAs(err, &le)
+	_, err = NewLayered(0, providerOnly{defaults})
+	r.ErrorAs(err, &le)
+	r.Equal(0, le.Layer())
+}
+
+func TestLayered_NonWalker(t *testing.T) {
+	r := require.New(t)
+	local, defaults := layeredFixture(r)
+	l, err := NewLayered(-1, providerOnly{local}, defaults)
+	r.NoError(err)
+
+	var names []string
+	r.NoError(l.WalkGroup(func(group roller.Group, last bool) bool {
+		names = append(names, group.ID+":"+group.Name)
+		return false
+	}))
+	r.Equal([]string{"member:", "mod:Local Mod"}, names, "higher layers should win even if they are not a Walker")
+}
+
+func TestLayered_Bundle(t *testing.T) {
+	r := require.New(t)
+	local, defaults := layeredFixture(r)
+	r.NoError(local.AddBundle(roller.Bundle{Name: "chat", Grant: []string{"chat.local"}}))
+	r.NoError(defaults.AddBundle(roller.Bundle{Name: "chat", Grant: []string{"chat.default"}}))
+	r.NoError(defaults.AddBundle(roller.Bundle{Name: "world", Grant: []string{"world.build"}}))
+	r.NoError(defaults.AddGroup(roller.Group{ID: "builder", Permission: roller.Entry{Include: []string{"chat", "world"}}}))
+	l, err := NewLayered(-1, providerOnly{brokenProvider{}}, local, defaults)
+	r.NoError(err)
+
+	b, err := l.Bundle("chat")
+	r.NoError(err)
+	r.Equal([]string{"chat.local"}, b.Grant)
+	_, err = l.Bundle("missing")
+	r.Equal(NewBundleNotFoundError("missing"), err)
+
+	l, err = NewLayered(0, local, defaults)
+	r.NoError(err)
+	list, err := roller.BasicProcessor{Provider: l}.Process(roller.RawList{Groups: []string{"builder"}})
+	r.NoError(err)
+	r.Equal([]string{"chat.local", "world.build"}, list.Permission)
+
+	var walked []roller.Bundle
+	r.NoError(l.WalkBundle(func(bundle roller.Bundle, last bool) bool {
+		walked = append(walked, bundle)
+		return false
+	}))
+	r.Equal([]roller.Bundle{{Name: "chat", Grant: []string{"chat.local"}}, {Name: "world", Grant: []string{"world.build"}}}, walked)
+
+	r.NoError(l.AddBundle(roller.Bundle{Name: "world", Grant: []string{"world.break"}}))
+	b, err = local.Bundle("world")
+	r.NoError(err, "bundles should be added to the writable layer")
+	r.Equal([]string{"world.break"}, b.Grant)
+	r.NoError(l.RemoveBundle("world"))
+	b, err = l.Bundle("world")
+	r.NoError(err)
+	r.Equal([]string{"world.build"}, b.Grant, "lower layers should still provide a removed bundle")
+
+	var buf bytes.Buffer
+	r.NoError(Export(&buf, l, nil))
+	dst, err := NewJSON(&bytes.Buffer{})
+	r.NoError(err)
+	_, err = Import(&buf, dst, nil, ImportMerge)
+	r.NoError(err)
+	b, err = dst.Bundle("chat")
+	r.NoError(err)
+	r.Equal([]string{"chat.local"}, b.Grant, "export should use the winning bundle")
+
+	rep, err := Import(bytes.NewBufferString(`{"version":1,"groups":[],"bundles":[{"name":"mining","grant":["world.mine"]}]}`), l, nil, ImportMerge)
+	r.NoError(err)
+	r.Equal([]string{"mining"}, rep.AddedBundles)
+	_, err = local.Bundle("mining")
+	r.NoError(err, "imported bundles should be added to the writable layer")
+
+	l, err = NewLayered(-1, local, defaults)
+	r.NoError(err)
+	r.Equal(ReadOnlyError{}, l.AddBundle(roller.Bundle{Name: "x"}))
+	l, err = NewLayered(0, storeOnly{local}, defaults)
+	r.NoError(err)
+	r.Equal(NewUnsupportedError("RemoveBundle"), l.RemoveBundle("chat"))
+}
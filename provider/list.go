@@ -0,0 +1,104 @@
+package provider
+
+import (
+	"github.com/Thunder33345/roller"
+	"sort"
+	"strings"
+)
+
+//Lister is a provider capable of listing groups page by page
+type Lister interface {
+	//ListGroups returns a page of groups matching ListOptions.Filter, ordered by ID
+	ListGroups(opts ListOptions) (GroupPage, error)
+}
+
+//ListOptions are the options of Lister.ListGroups
+type ListOptions struct {
+	Filter GroupFilter
+	//Cursor is the GroupPage.Next of the previous page, "" for the first page
+	Cursor string
+	//Limit is the max amount of groups in a page, 0 returns every remaining group
+	Limit int
+}
+
+//GroupPage is a page of groups returned by Lister.ListGroups
+type GroupPage struct {
+	Groups []roller.Group
+	//Next is the cursor of the next page, "" if this is the last page
+	//it should be treated as opaque
+	Next string
+}
+
+//GroupFilter filters groups, every set condition must match, a zero GroupFilter matches every group
+type GroupFilter struct {
+	//NamePrefix and RefNamePrefix match the prefix of Group.Name and Group.RefName
+	NamePrefix    string
+	RefNamePrefix string
+	//MinWeight and MaxWeight are the inclusive bounds of Group.Weight
+	MinWeight *int
+	MaxWeight *int
+	//Grants matches groups where Group.Permission grants the node
+	Grants string
+	//Comparator is used to match Grants, defaults to exact match
+	//an ImplicitComparator will also match wildcard grants
+	Comparator roller.Comparator
+	//HasFlag matches groups with the flag in Group.Flags
+	HasFlag string
+}
+
+//Match returns true if the group matches every set condition
+func (f GroupFilter) Match(g roller.Group) bool {
+	if !strings.HasPrefix(g.Name, f.NamePrefix) || !strings.HasPrefix(g.RefName, f.RefNamePrefix) {
+		return false
+	}
+	if (f.MinWeight != nil && g.Weight < *f.MinWeight) || (f.MaxWeight != nil && g.Weight > *f.MaxWeight) {
+		return false
+	}
+	if f.HasFlag != "" {
+		if _, ok := g.Flags[f.HasFlag]; !ok {
+			return false
+		}
+	}
+	if f.Grants != "" {
+		if f.Comparator != nil {
+			return f.Comparator.HasPermission(roller.List{Permission: g.Permission.Grant}, f.Grants)
+		}
+		for _, n := range g.Permission.Grant {
+			if n == f.Grants {
+				return true
+			}
+		}
+		return false
+	}
+	return true
+}
+
+//pager collects a page of matching groups that are fed in ID order
+type pager struct {
+	opts ListOptions
+	page GroupPage
+}
+
+//add feeds a group to pager, returns false once the page is full
+func (p *pager) add(g roller.Group) bool {
+	if !p.opts.Filter.Match(g) {
+		return true
+	}
+	if p.opts.Limit > 0 && len(p.page.Groups) == p.opts.Limit {
+		p.page.Next = p.page.Groups[len(p.page.Groups)-1].ID
+		return false
+	}
+	p.page.Groups = append(p.page.Groups, g)
+	return true
+}
+
+//listSorted pages through groups sorted by ID
+func listSorted(gs []roller.Group, opts ListOptions) GroupPage {
+	p := pager{opts: opts}
+	i := sort.Search(len(gs), func(i int) bool {
+		return gs[i].ID > opts.Cursor
+	})
+	for ; i < len(gs) && p.add(gs[i]); i++ {
+	}
+	return p.page
+}
@@ -0,0 +1,125 @@
+package provider
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/Thunder33345/roller"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestGroupFilter_Match(t *testing.T) {
+	one, five := 1, 5
+	g := roller.Group{ID: "1", Name: "Moderator", RefName: "staff.mod", Weight: 3,
+		Permission: roller.Entry{Grant: []string{"chat.kick", "world*"}},
+		Flags:      map[string]roller.FlagEntry{"night": {}},
+	}
+	tests := []struct {
+		name   string
+		filter GroupFilter
+		want   bool
+	}{
+		{name: "Zero", filter: GroupFilter{}, want: true},
+		{name: "Name prefix", filter: GroupFilter{NamePrefix: "Mod"}, want: true},
+		{name: "Name prefix mismatch", filter: GroupFilter{NamePrefix: "Adm"}, want: false},
+		{name: "RefName prefix", filter: GroupFilter{RefNamePrefix: "staff."}, want: true},
+		{name: "RefName prefix mismatch", filter: GroupFilter{RefNamePrefix: "vip."}, want: false},
+		{name: "Weight range", filter: GroupFilter{MinWeight: &one, MaxWeight: &five}, want: true},
+		{name: "Weight too low", filter: GroupFilter{MinWeight: &five}, want: false},
+		{name: "Weight too high", filter: GroupFilter{MaxWeight: &one}, want: false},
+		{name: "Grants", filter: GroupFilter{Grants: "chat.kick"}, want: true},
+		{name: "Grants exact only", filter: GroupFilter{Grants: "world.build"}, want: false},
+		{name: "Grants wildcard", filter: GroupFilter{Grants: "world.build", Comparator: roller.ImplicitComparator{Deliminator: ".", Terminator: "*"}}, want: true},
+		{name: "Has flag", filter: GroupFilter{HasFlag: "night"}, want: true},
+		{name: "Missing flag", filter: GroupFilter{HasFlag: "day"}, want: false},
+		{name: "Combined", filter: GroupFilter{NamePrefix: "Mod", HasFlag: "day"}, want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.want, tt.filter.Match(g))
+		})
+	}
+}
+
+func testLister(t *testing.T, l Lister, add func(g roller.Group)) {
+	r := require.New(t)
+	for i := 9; i >= 0; i-- {
+		g := roller.Group{ID: fmt.Sprintf("g%d", i), Weight: i}
+		if i%2 == 0 {
+			g.Flags = map[string]roller.FlagEntry{"even": {}}
+		}
+		add(g)
+	}
+
+	ids := func(p GroupPage) []string {
+		var o []string
+		for _, g := range p.Groups {
+			o = append(o, g.ID)
+		}
+		return o
+	}
+
+	p, err := l.ListGroups(ListOptions{})
+	r.NoError(err)
+	r.Len(p.Groups, 10)
+	r.Equal("", p.Next)
+
+	opts := ListOptions{Filter: GroupFilter{HasFlag: "even"}, Limit: 2}
+	var pages [][]string
+	for {
+		p, err = l.ListGroups(opts)
+		r.NoError(err)
+		pages = append(pages, ids(p))
+		if p.Next == "" {
+			break
+		}
+		opts.Cursor = p.Next
+	}
+	r.Equal([][]string{{"g0", "g2"}, {"g4", "g6"}, {"g8"}}, pages)
+
+	three := 3
+	p, err = l.ListGroups(ListOptions{Filter: GroupFilter{MaxWeight: &three}, Limit: 4})
+	r.NoError(err)
+	r.Equal([]string{"g0", "g1", "g2", "g3"}, ids(p))
+	r.Equal("", p.Next, "no more matching groups should end the listing")
+
+	p, err = l.ListGroups(ListOptions{Cursor: "g9"})
+	r.NoError(err)
+	r.Empty(p.Groups)
+}
+
+func TestJSON_ListGroups(t *testing.T) {
+	j, err := NewJSON(&bytes.Buffer{})
+	require.NoError(t, err)
+	testLister(t, j, func(g roller.Group) {
+		require.NoError(t, j.AddGroup(g))
+	})
+}
+
+func TestBolt_ListGroups(t *testing.T) {
+	r := require.New(t)
+	b := newTestBolt(r, t.TempDir())
+	defer func() {
+		r.NoError(b.Close())
+	}()
+	testLister(t, b, func(g roller.Group) {
+		r.NoError(b.AddGroup(g))
+	})
+}
+
+func TestJSON_WalkGroupSnapshot(t *testing.T) {
+	r := require.New(t)
+	j, err := NewJSON(&bytes.Buffer{})
+	r.NoError(err)
+	r.NoError(j.AddGroup(roller.Group{ID: "1"}))
+	r.NoError(j.AddGroup(roller.Group{ID: "2"}))
+
+	var walked []string
+	r.NoError(j.WalkGroup(func(group roller.Group, last bool) bool {
+		walked = append(walked, group.ID)
+		r.NoError(j.AddGroup(roller.Group{ID: group.ID + "0"}), "writes should not be blocked by the walk")
+		return false
+	}))
+	r.Equal([]string{"1", "2"}, walked)
+	r.Equal([]string{"1", "2", "10", "20"}, txIDs(r, j))
+}
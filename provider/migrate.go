@@ -0,0 +1,81 @@
+package provider
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+)
+
+//JSONVersion is the current version of the JSON file format, it's the version written by JSON.Save
+//version 0 is the legacy bare group array, version 1 is the {"version": 1, "groups": [...], "bundles": [...]} envelope
+const JSONVersion = 1
+
+//jsonMigration upgrades a raw JSON file from one version to the next one
+//the returned data must have a "version" field that's higher than the version it was given
+type jsonMigration func(data json.RawMessage) (json.RawMessage, error)
+
+//jsonMigrations are the migrations used by JSON, keyed by the version they upgrade from
+var jsonMigrations = map[int]jsonMigration{
+	0: migrateBareArray,
+}
+
+//migrateJSON runs the required migrations in a chain on data, returns data of JSONVersion
+//returns UnsupportedVersionError if data is newer than JSONVersion, or MigrationError if a migration is missing or failed
+func migrateJSON(data json.RawMessage, migrations map[int]jsonMigration) (json.RawMessage, error) {
+	v, err := jsonFileVersion(data)
+	if err != nil {
+		return nil, err
+	}
+	if v > JSONVersion {
+		return nil, NewUnsupportedVersionError(v, JSONVersion)
+	}
+	for v < JSONVersion {
+		m, ok := migrations[v]
+		if !ok {
+			return nil, NewMigrationError(v, errors.New("no migration registered"))
+		}
+		if data, err = m(data); err != nil {
+			return nil, NewMigrationError(v, err)
+		}
+		next, err := jsonFileVersion(data)
+		if err != nil {
+			return nil, NewMigrationError(v, err)
+		}
+		if next <= v {
+			return nil, NewMigrationError(v, errors.New("migration did not increase the version"))
+		}
+		v = next
+	}
+	return data, nil
+}
+
+//jsonFileVersion returns the version of a raw JSON file
+//a bare array is version 0, an object without a version is version 1
+//returns UnsupportedVersionError if an object has a version below 1, as only a bare array can be version 0
+func jsonFileVersion(data json.RawMessage) (int, error) {
+	t := bytes.TrimLeft(data, " \t\r\n")
+	if len(t) == 0 || t[0] != '{' {
+		return 0, nil
+	}
+	var h struct {
+		Version *int `json:"version"`
+	}
+	if err := json.Unmarshal(data, &h); err != nil {
+		return 0, err
+	}
+	if h.Version == nil {
+		return 1, nil
+	}
+	if *h.Version < 1 {
+		return 0, NewUnsupportedVersionError(*h.Version, JSONVersion)
+	}
+	return *h.Version, nil
+}
+
+//migrateBareArray wraps a version 0 bare group array into the version 1 envelope
+func migrateBareArray(data json.RawMessage) (json.RawMessage, error) {
+	return json.Marshal(struct {
+		Version int             `json:"version"`
+		Groups  json.RawMessage `json:"groups"`
+	}{Version: 1, Groups: data})
+}
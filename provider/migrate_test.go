@@ -0,0 +1,130 @@
+package provider
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"github.com/Thunder33345/roller"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestJSON_Versions(t *testing.T) {
+	tests := []struct {
+		name       string
+		file       string
+		wantIDs    []string
+		wantBundle bool
+		wantErr    bool
+	}{
+		{
+			name:    "Bare array",
+			file:    `[{"name":"test","id":"100"},{"name":"test2","id":"101"}]`,
+			wantIDs: []string{"100", "101"},
+		}, {
+			name:       "Unversioned envelope",
+			file:       `{"groups":[{"id":"100"}],"bundles":[{"name":"chat"}]}`,
+			wantIDs:    []string{"100"},
+			wantBundle: true,
+		}, {
+			name:       "Current",
+			file:       `{"version":1,"groups":[{"id":"100"}],"bundles":[{"name":"chat"}]}`,
+			wantIDs:    []string{"100"},
+			wantBundle: true,
+		}, {
+			name:    "Bare array unknown field",
+			file:    `[{"id":"100","unknown":1}]`,
+			wantErr: true,
+		}, {
+			name:    "Envelope unknown field",
+			file:    `{"version":1,"groups":[],"unknown":1}`,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := require.New(t)
+			j, err := NewJSON(bytes.NewBufferString(tt.file))
+			if tt.wantErr {
+				r.Error(err)
+				return
+			}
+			r.NoError(err)
+			var ids []string
+			r.NoError(j.WalkGroup(func(group roller.Group, last bool) bool {
+				ids = append(ids, group.ID)
+				return false
+			}))
+			r.Equal(tt.wantIDs, ids)
+			_, err = j.Bundle("chat")
+			r.Equal(tt.wantBundle, err == nil)
+		})
+	}
+
+	_, err := NewJSON(bytes.NewBufferString(`{"version":2,"groups":[]}`))
+	var ue UnsupportedVersionError
+	require.ErrorAs(t, err, &ue)
+	require.Equal(t, 2, ue.Version())
+	require.Equal(t, "file version 2 is newer than supported version 1", ue.Error())
+
+	_, err = NewJSON(bytes.NewBufferString(`{"version":0,"groups":[]}`))
+	require.ErrorAs(t, err, &ue)
+	require.Equal(t, 0, ue.Version())
+	require.Equal(t, "file version 0 is invalid, only a bare group array can be version 0", ue.Error())
+}
+
+func TestJSON_migrationChain(t *testing.T) {
+	r := require.New(t)
+	//a legacy file that used "uid" instead of "id"
+	migrations := map[int]jsonMigration{0: func(data json.RawMessage) (json.RawMessage, error) {
+		var old []struct {
+			UID string `json:"uid"`
+		}
+		if err := json.Unmarshal(data, &old); err != nil {
+			return nil, err
+		}
+		groups := make([]map[string]string, 0, len(old))
+		for _, g := range old {
+			groups = append(groups, map[string]string{"id": g.UID})
+		}
+		return json.Marshal(map[string]interface{}{"version": 1, "groups": groups})
+	}}
+	got, err := migrateJSON(json.RawMessage(`[{"uid":"100"}]`), migrations)
+	r.NoError(err)
+	r.JSONEq(`{"version":1,"groups":[{"id":"100"}]}`, string(got))
+
+	fail := errors.New("fail")
+	migrations[0] = func(data json.RawMessage) (json.RawMessage, error) {
+		return nil, fail
+	}
+	_, err = migrateJSON(json.RawMessage(`[]`), migrations)
+	var me MigrationError
+	r.ErrorAs(err, &me)
+	r.Equal(0, me.From())
+	r.ErrorIs(err, fail)
+
+	migrations[0] = func(data json.RawMessage) (json.RawMessage, error) {
+		return json.RawMessage(`[]`), nil
+	}
+	_, err = migrateJSON(json.RawMessage(`[]`), migrations)
+	r.ErrorAs(err, &me)
+	r.Equal("failed to migrate from version 0: migration did not increase the version", me.Error())
+
+	_, err = migrateJSON(json.RawMessage(`[]`), map[int]jsonMigration{})
+	r.ErrorAs(err, &me)
+	r.Equal("failed to migrate from version 0: no migration registered", me.Error())
+}
+
+func TestJSON_migrateJSON(t *testing.T) {
+	r := require.New(t)
+	got, err := migrateJSON(json.RawMessage(`[{"id":"100"}]`), jsonMigrations)
+	r.NoError(err)
+	r.JSONEq(`{"version":1,"groups":[{"id":"100"}]}`, string(got))
+
+	got, err = migrateJSON(json.RawMessage(`{"version":1,"groups":[]}`), jsonMigrations)
+	r.NoError(err)
+	r.JSONEq(`{"version":1,"groups":[]}`, string(got))
+
+	_, err = migrateJSON(json.RawMessage(`{"version":"1"}`), jsonMigrations)
+	r.Error(err)
+}
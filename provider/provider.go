@@ -6,6 +6,7 @@ import (
 )
 
 var _ roller.GroupProvider = (GroupStorer)(nil)
+var _ roller.BundleProvider = (BundleStorer)(nil)
 
 //GroupStorer is something that is capable of store and provide groups
 type GroupStorer interface { //todo set a better name
@@ -14,6 +15,36 @@ type GroupStorer interface { //todo set a better name
 	RemoveGroup(id string) error
 }
 
+//BundleStorer is something that is capable of store and provide bundles
+type BundleStorer interface {
+	AddBundle(bundle roller.Bundle) error
+	Bundle(name string) (roller.Bundle, error)
+	RemoveBundle(name string) error
+}
+
+//BundleWalker is a BundleStorer that can iterate through its bundles
+type BundleWalker interface {
+	BundleStorer
+	//WalkBundle is the same as Walker.WalkGroup, but for Bundle
+	WalkBundle(func(bundle roller.Bundle, last bool) (halt bool)) error
+}
+
+//Reviser is a provider that tracks Group.Revision, to allow conditional updates
+//revisions start from 1, a revision of 0 means the group doesn't exist
+type Reviser interface {
+	GroupStorer
+	//CreateGroup stores a new group, returns GroupExistsError if the group already exists
+	//returns the revision of the stored group
+	CreateGroup(group roller.Group) (revision uint64, err error)
+	//UpdateGroup replaces an existing group, returns GroupNotFoundError if the group doesn't exist
+	//returns the revision of the stored group
+	UpdateGroup(group roller.Group) (revision uint64, err error)
+	//CompareAndSwapGroup stores the group only if its current revision is expectedRevision
+	//an expectedRevision of 0 requires the group to not exist yet
+	//returns ConflictError if the revision doesn't match, or the revision of the stored group
+	CompareAndSwapGroup(group roller.Group, expectedRevision uint64) (revision uint64, err error)
+}
+
 //Walker is an iterable provider
 type Walker interface {
 	GroupStorer
@@ -25,6 +56,28 @@ type Walker interface {
 	WalkGroup(func(group roller.Group, last bool) (halt bool)) error
 }
 
+//Transactor is a provider that is capable of applying multiple changes atomically
+type Transactor interface {
+	GroupStorer
+	//Update runs fn with a transaction, fn must only use tx to access the provider
+	//changes staged through tx are committed atomically if fn returns nil, and discarded if fn returns an error
+	//fn may be run more than once, such as when a provider retries a conflicting transaction, so it must not have other side effects
+	//returns the error of fn, or an error if committing failed
+	//returns TxConflictError if the provider was changed by others while fn ran, and the provider gave up retrying
+	Update(fn func(tx GroupTx) error) error
+}
+
+//GroupTx is a transaction of a Transactor, it sees the provider with its own staged changes applied
+//it must not be used after the fn it's given to returns
+type GroupTx interface {
+	GroupStorer
+	//WalkGroup is the same as Walker.WalkGroup
+	WalkGroup(func(group roller.Group, last bool) (halt bool)) error
+	//SaveOnCommit requests the provider to be saved as part of the commit, providers that can't save ignore it
+	//a failed save fails the commit
+	SaveOnCommit()
+}
+
 //Saver is a provider that is capable of saving
 type Saver interface {
 	GroupStorer
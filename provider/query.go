@@ -0,0 +1,111 @@
+package provider
+
+import (
+	"github.com/Thunder33345/roller"
+	"sort"
+)
+
+//GrantMatch is a group entry that grants a queried node
+type GrantMatch struct {
+	//Group is the ID of the group
+	Group string
+	//Flag is the name of the flag entry, "" for Group.Permission
+	Flag string
+	//Grants are the granted nodes that matched, such as the node itself or a wildcard covering it
+	Grants []string
+}
+
+//Query answers who can do what, by looking up groups and RawList in reverse
+type Query struct {
+	//Comparator decides if a grant matches the node, an ImplicitComparator will also match wildcard grants
+	Comparator roller.Comparator
+	//Expander is used to expand Entry.Include before matching, includes are ignored if nil
+	Expander roller.EntryExpander
+}
+
+//Grants returns every group and flag entry of w that grants node
+//matches are ordered the same as w walks the groups, with Group.Permission before flags sorted by name
+//returns error if walking fails or an Entry.Include can't be expanded
+func (q Query) Grants(w Walker, node string) ([]GrantMatch, error) {
+	var matches []GrantMatch
+	var err error
+	werr := w.WalkGroup(func(group roller.Group, last bool) bool {
+		var m []string
+		if m, err = q.match(group.Permission, node); err != nil {
+			return true
+		}
+		if len(m) > 0 {
+			matches = append(matches, GrantMatch{Group: group.ID, Grants: m})
+		}
+
+		names := make([]string, 0, len(group.Flags))
+		for n := range group.Flags {
+			names = append(names, n)
+		}
+		sort.Strings(names)
+		for _, n := range names {
+			if m, err = q.match(group.Flags[n].Entry, node); err != nil {
+				return true
+			}
+			if len(m) > 0 {
+				matches = append(matches, GrantMatch{Group: group.ID, Flag: n, Grants: m})
+			}
+		}
+		return false
+	})
+	if werr != nil {
+		return nil, werr
+	}
+	if err != nil {
+		return nil, err
+	}
+	return matches, nil
+}
+
+//Subjects returns the sorted subjects of lists whose processed List has node
+//the RawList are processed with flags if any are given
+//returns error if walking fails or any RawList fails to be processed
+func (q Query) Subjects(lists RawListStorer, p roller.Processor, node string, flags ...string) ([]string, error) {
+	var subjects []string
+	var err error
+	werr := lists.WalkRawList(func(subject string, list roller.RawList, last bool) bool {
+		var l roller.List
+		if len(flags) > 0 {
+			l, err = p.ProcessFlags(list, flags...)
+		} else {
+			l, err = p.Process(list)
+		}
+		if err != nil {
+			return true
+		}
+		if q.Comparator.HasPermission(l, node) {
+			subjects = append(subjects, subject)
+		}
+		return false
+	})
+	if werr != nil {
+		return nil, werr
+	}
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(subjects)
+	return subjects, nil
+}
+
+//match returns the grants of e that match node
+func (q Query) match(e roller.Entry, node string) ([]string, error) {
+	if q.Expander != nil && len(e.Include) > 0 {
+		var err error
+		if e, err = q.Expander.ExpandEntry(e); err != nil {
+			return nil, err
+		}
+	}
+	var m []string
+	for _, g := range e.Grant {
+		if q.Comparator.HasPermission(roller.List{Permission: []string{g}}, node) {
+			m = append(m, g)
+		}
+	}
+	return m, nil
+}
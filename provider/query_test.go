@@ -0,0 +1,81 @@
+package provider
+
+import (
+	"bytes"
+	"github.com/Thunder33345/roller"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func queryFixture(r *require.Assertions) (*JSON, *MemoryRawLists) {
+	j, err := NewJSON(&bytes.Buffer{})
+	r.NoError(err)
+	r.NoError(j.AddBundle(roller.Bundle{Name: "ops", Grant: []string{"server.shutdown"}}))
+	r.NoError(j.AddGroup(roller.Group{ID: "member", Weight: 1, Permission: roller.Entry{Grant: []string{"chat"}}}))
+	r.NoError(j.AddGroup(roller.Group{ID: "admin", Weight: 3, Permission: roller.Entry{Grant: []string{"server*", "chat"}}}))
+	r.NoError(j.AddGroup(roller.Group{ID: "mod", Weight: 2, Permission: roller.Entry{Grant: []string{"kick"}}, Flags: map[string]roller.FlagEntry{
+		"emergency": {Entry: roller.Entry{Grant: []string{"server.shutdown"}}},
+		"bundled":   {Entry: roller.Entry{Include: []string{"ops"}}},
+		"other":     {Entry: roller.Entry{Grant: []string{"server.restart"}}},
+	}}))
+	r.NoError(j.AddGroup(roller.Group{ID: "limited", Weight: 4, Permission: roller.Entry{Revoke: []string{"server.shutdown"}}}))
+
+	l := &MemoryRawLists{}
+	r.NoError(l.SetRawList("alice", roller.RawList{Groups: []string{"member"}}))
+	r.NoError(l.SetRawList("bob", roller.RawList{Groups: []string{"admin"}}))
+	r.NoError(l.SetRawList("carol", roller.RawList{Groups: []string{"mod"}}))
+	r.NoError(l.SetRawList("dave", roller.RawList{Groups: []string{"member"}, Overwrites: roller.Entry{Grant: []string{"server.shutdown"}}}))
+	r.NoError(l.SetRawList("erin", roller.RawList{Groups: []string{"admin", "limited"}}))
+	return j, l
+}
+
+func TestQuery_Grants(t *testing.T) {
+	r := require.New(t)
+	j, _ := queryFixture(r)
+	implicit := roller.ImplicitComparator{Deliminator: ".", Terminator: "*"}
+
+	got, err := Query{Comparator: roller.ExplicitComparator{}}.Grants(j, "server.shutdown")
+	r.NoError(err)
+	r.Equal([]GrantMatch{{Group: "mod", Flag: "emergency", Grants: []string{"server.shutdown"}}}, got)
+
+	got, err = Query{Comparator: implicit, Expander: roller.BasicProcessor{Provider: j}}.Grants(j, "server.shutdown")
+	r.NoError(err)
+	r.Equal([]GrantMatch{
+		{Group: "admin", Grants: []string{"server*"}},
+		{Group: "mod", Flag: "bundled", Grants: []string{"server.shutdown"}},
+		{Group: "mod", Flag: "emergency", Grants: []string{"server.shutdown"}},
+	}, got)
+
+	got, err = Query{Comparator: implicit}.Grants(j, "nothing")
+	r.NoError(err)
+	r.Empty(got)
+
+	r.NoError(j.AddGroup(roller.Group{ID: "broken", Permission: roller.Entry{Include: []string{"missing"}}}))
+	_, err = Query{Comparator: implicit, Expander: roller.BasicProcessor{Provider: j}}.Grants(j, "server.shutdown")
+	var ue roller.UnknownBundleError
+	r.ErrorAs(err, &ue)
+}
+
+func TestQuery_Subjects(t *testing.T) {
+	r := require.New(t)
+	j, l := queryFixture(r)
+	q := Query{Comparator: roller.ImplicitComparator{Deliminator: ".", Terminator: "*"}}
+
+	got, err := q.Subjects(l, roller.BasicProcessor{Provider: j}, "server.shutdown")
+	r.NoError(err)
+	r.Equal([]string{"bob", "dave", "erin"}, got, "exact revoke should not cut into a wildcard grant")
+
+	wildcard := roller.ImplicitComparator{Deliminator: ".", Terminator: "*"}
+	got, err = q.Subjects(l, roller.BasicProcessor{Provider: j, Wildcard: &wildcard}, "server.shutdown")
+	r.NoError(err)
+	r.Equal([]string{"bob", "dave"}, got, "exclusions should be respected")
+
+	got, err = q.Subjects(l, roller.BasicProcessor{Provider: j}, "server.shutdown", "emergency")
+	r.NoError(err)
+	r.Equal([]string{"bob", "carol", "dave", "erin"}, got)
+
+	r.NoError(l.SetRawList("frank", roller.RawList{Groups: []string{"ghost"}}))
+	_, err = q.Subjects(l, roller.BasicProcessor{Provider: j}, "server.shutdown")
+	var me roller.MissingGroupError
+	r.ErrorAs(err, &me)
+}
@@ -0,0 +1,77 @@
+package provider
+
+import (
+	"github.com/Thunder33345/roller"
+	"sort"
+	"sync"
+)
+
+//RawListStorer is something that is capable of store and provide RawList keyed by subject, such as a user ID
+type RawListStorer interface {
+	RawList(subject string) (roller.RawList, error)
+	SetRawList(subject string, list roller.RawList) error
+	RemoveRawList(subject string) error
+	//WalkRawList is the same as Walker.WalkGroup, but for RawList
+	WalkRawList(func(subject string, list roller.RawList, last bool) (halt bool)) error
+}
+
+var _ RawListStorer = (*MemoryRawLists)(nil)
+
+//MemoryRawLists is a RawListStorer that only keeps RawList in memory
+//the zero value is ready to use
+type MemoryRawLists struct {
+	lists map[string]roller.RawList
+	m     sync.RWMutex
+}
+
+func (s *MemoryRawLists) RawList(subject string) (roller.RawList, error) {
+	s.m.RLock()
+	defer s.m.RUnlock()
+	l, ok := s.lists[subject]
+	if !ok {
+		return roller.RawList{}, NewRawListNotFoundError(subject)
+	}
+	return l, nil
+}
+
+func (s *MemoryRawLists) SetRawList(subject string, list roller.RawList) error {
+	s.m.Lock()
+	defer s.m.Unlock()
+	if s.lists == nil {
+		s.lists = make(map[string]roller.RawList)
+	}
+	s.lists[subject] = list
+	return nil
+}
+
+func (s *MemoryRawLists) RemoveRawList(subject string) error {
+	s.m.Lock()
+	defer s.m.Unlock()
+	if _, ok := s.lists[subject]; !ok {
+		return NewRawListNotFoundError(subject)
+	}
+	delete(s.lists, subject)
+	return nil
+}
+
+//WalkRawList walks a snapshot of every RawList ordered by subject, so the callback may alter MemoryRawLists
+func (s *MemoryRawLists) WalkRawList(f func(subject string, list roller.RawList, last bool) (halt bool)) error {
+	s.m.RLock()
+	subjects := make([]string, 0, len(s.lists))
+	for k := range s.lists {
+		subjects = append(subjects, k)
+	}
+	lists := make(map[string]roller.RawList, len(s.lists))
+	for k, v := range s.lists {
+		lists[k] = v
+	}
+	s.m.RUnlock()
+
+	sort.Strings(subjects)
+	for i, k := range subjects {
+		if f(k, lists[k], len(subjects)-1 == i) {
+			return nil
+		}
+	}
+	return nil
+}
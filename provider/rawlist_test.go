@@ -0,0 +1,38 @@
+package provider
+
+import (
+	"github.com/Thunder33345/roller"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestMemoryRawLists(t *testing.T) {
+	r := require.New(t)
+	s := &MemoryRawLists{}
+	_, err := s.RawList("alice")
+	var nf RawListNotFoundError
+	r.ErrorAs(err, &nf)
+	r.Equal("alice", nf.Subject())
+	r.ErrorAs(s.RemoveRawList("alice"), &nf)
+
+	r.NoError(s.SetRawList("bob", roller.RawList{Groups: []string{"mod"}}))
+	r.NoError(s.SetRawList("alice", roller.RawList{Groups: []string{"member"}}))
+	r.NoError(s.SetRawList("carol", roller.RawList{}))
+	l, err := s.RawList("alice")
+	r.NoError(err)
+	r.Equal([]string{"member"}, l.Groups)
+
+	var subjects []string
+	var lasts []bool
+	r.NoError(s.WalkRawList(func(subject string, list roller.RawList, last bool) bool {
+		subjects = append(subjects, subject)
+		lasts = append(lasts, last)
+		r.NoError(s.RemoveRawList(subject), "should not deadlock")
+		return subject == "bob"
+	}))
+	r.Equal([]string{"alice", "bob"}, subjects)
+	r.Equal([]bool{false, false}, lasts)
+
+	_, err = s.RawList("carol")
+	r.NoError(err)
+}
@@ -0,0 +1,324 @@
+package provider
+
+import (
+	"errors"
+	"github.com/Thunder33345/roller"
+	"strings"
+)
+
+//TenantSeparator separates the tenant from the group ID, Group.RefName, and RawList subject inside the underlying store
+const TenantSeparator = "/"
+
+var _ roller.TenantProvider = (*Tenants)(nil)
+var _ roller.BundleProvider = (*Tenants)(nil)
+
+//Tenants resolves tenant scoped groups kept in a single GroupStorer, with optional global groups every tenant can reference
+//it's intended to be used as the BasicProcessor.Provider, so groups are resolved within RawList.Tenant first
+type Tenants struct {
+	store GroupStorer
+	//global provides groups shared by every tenant, nil if there's none
+	global roller.GroupProvider
+}
+
+//NewTenants creates Tenants out of store holding the tenant scoped groups, and global holding the shared groups
+//global can be nil, or the same store, as tenant scoped groups never collide with unscoped IDs
+//IDs containing TenantSeparator are never looked up in global, so a shared store doesn't expose tenant scoped groups as global ones
+//walking a shared store directly still walks the tenant scoped groups, use Tenants.WalkGroup to only walk the global groups
+func NewTenants(store GroupStorer, global roller.GroupProvider) *Tenants {
+	return &Tenants{store: store, global: global}
+}
+
+//Scoped returns the view of tenant, see NewScoped
+func (t *Tenants) Scoped(tenant string) (*Scoped, error) {
+	return NewScoped(t.store, tenant, t.global)
+}
+
+//Group returns the global group, used for RawList without a tenant
+//returns GroupNotFoundError for IDs containing TenantSeparator
+func (t *Tenants) Group(id string) (roller.Group, error) {
+	if t.global == nil || strings.Contains(id, TenantSeparator) {
+		return roller.Group{}, NewGroupNotFoundError(id)
+	}
+	return t.global.Group(id)
+}
+
+//WalkGroup walks the global groups if global is a Walker, groups with an ID containing TenantSeparator are skipped
+func (t *Tenants) WalkGroup(f func(group roller.Group, last bool) (halt bool)) error {
+	w, ok := t.global.(Walker)
+	if !ok {
+		return nil
+	}
+	var gs []roller.Group
+	if err := w.WalkGroup(func(group roller.Group, last bool) bool {
+		if !strings.Contains(group.ID, TenantSeparator) {
+			gs = append(gs, group)
+		}
+		return false
+	}); err != nil {
+		return err
+	}
+	for i, g := range gs {
+		if f(g, len(gs)-1 == i) {
+			return nil
+		}
+	}
+	return nil
+}
+
+//Bundle returns the bundle from global, or from store if global doesn't have it, bundles are shared by every tenant
+//global and store are skipped if they are not a roller.BundleProvider
+//an error other than BundleNotFoundError is returned as is
+func (t *Tenants) Bundle(name string) (roller.Bundle, error) {
+	for _, p := range []interface{}{t.global, t.store} {
+		bp, ok := p.(roller.BundleProvider)
+		if !ok {
+			continue
+		}
+		b, err := bp.Bundle(name)
+		if err != nil {
+			var nf BundleNotFoundError
+			if errors.As(err, &nf) {
+				continue
+			}
+			return roller.Bundle{}, err
+		}
+		return b, nil
+	}
+	return roller.Bundle{}, NewBundleNotFoundError(name)
+}
+
+//TenantGroup returns the group of tenant, or the global group if tenant doesn't have it
+func (t *Tenants) TenantGroup(tenant string, id string) (roller.Group, error) {
+	s, err := t.Scoped(tenant)
+	if err != nil {
+		return roller.Group{}, err
+	}
+	return s.Group(id)
+}
+
+var _ Walker = (*Scoped)(nil)
+
+//Scoped is a view of a GroupStorer that only sees the groups of a single tenant, it works with any store such as JSON and Bolt
+//groups are stored with their ID and RefName prefixed by the tenant and TenantSeparator, so tenants never see each other's groups
+//groups returned by Scoped have the prefix removed
+type Scoped struct {
+	store  GroupStorer
+	tenant string
+	//global provides groups shared by every tenant, nil if there's none
+	global roller.GroupProvider
+}
+
+//NewScoped creates the view of tenant over store
+//global is used by Group when the tenant doesn't have the group, it can be nil
+//returns InvalidTenantError if tenant is empty or contains TenantSeparator
+func NewScoped(store GroupStorer, tenant string, global roller.GroupProvider) (*Scoped, error) {
+	if err := checkTenant(tenant); err != nil {
+		return nil, err
+	}
+	return &Scoped{store: store, tenant: tenant, global: global}, nil
+}
+
+//Tenant returns the tenant of the view
+func (s *Scoped) Tenant() string {
+	return s.tenant
+}
+
+//Group returns the group of the tenant, or the global group if the tenant doesn't have it
+//an ID containing TenantSeparator is never looked up globally, so other tenants can't be reached through a store shared with global
+func (s *Scoped) Group(id string) (roller.Group, error) {
+	g, err := s.store.Group(s.key(id))
+	if err == nil {
+		return s.unscope(g), nil
+	}
+	var nf GroupNotFoundError
+	if !errors.As(err, &nf) {
+		return roller.Group{}, err
+	}
+	if s.global == nil || strings.Contains(id, TenantSeparator) {
+		return roller.Group{}, NewGroupNotFoundError(id)
+	}
+	return s.global.Group(id)
+}
+
+//GroupByRefName returns the group of the tenant with the given Group.RefName, global groups are not included
+//the index of the store is used if it has one, such as Bolt, otherwise the store must be a Walker
+func (s *Scoped) GroupByRefName(refName string) (roller.Group, error) {
+	if r, ok := s.store.(interface {
+		GroupByRefName(refName string) (roller.Group, error)
+	}); ok {
+		g, err := r.GroupByRefName(s.key(refName))
+		if err != nil {
+			var nf RefNameNotFoundError
+			if errors.As(err, &nf) {
+				return roller.Group{}, NewRefNameNotFoundError(refName)
+			}
+			return roller.Group{}, err
+		}
+		return s.unscope(g), nil
+	}
+	var found *roller.Group
+	err := s.WalkGroup(func(group roller.Group, last bool) bool {
+		if group.RefName == refName {
+			found = &group
+			return true
+		}
+		return false
+	})
+	if err != nil {
+		return roller.Group{}, err
+	}
+	if found == nil {
+		return roller.Group{}, NewRefNameNotFoundError(refName)
+	}
+	return *found, nil
+}
+
+//AddGroup stores the group under the tenant, global groups are never written to
+func (s *Scoped) AddGroup(group roller.Group) error {
+	return s.store.AddGroup(s.scope(group))
+}
+
+//RemoveGroup removes the group of the tenant, global groups are never removed
+func (s *Scoped) RemoveGroup(id string) error {
+	err := s.store.RemoveGroup(s.key(id))
+	var nf GroupNotFoundError
+	if errors.As(err, &nf) {
+		return NewGroupNotFoundError(id)
+	}
+	return err
+}
+
+//WalkGroup walks the groups of the tenant in the order of the store, global groups are not walked
+//nothing is walked if the store is not a Walker
+func (s *Scoped) WalkGroup(f func(group roller.Group, last bool) (halt bool)) error {
+	w, ok := s.store.(Walker)
+	if !ok {
+		return nil
+	}
+	var gs []roller.Group
+	prefix := s.key("")
+	if err := w.WalkGroup(func(group roller.Group, last bool) bool {
+		if strings.HasPrefix(group.ID, prefix) {
+			gs = append(gs, s.unscope(group))
+		}
+		return false
+	}); err != nil {
+		return err
+	}
+	for i, g := range gs {
+		if f(g, len(gs)-1 == i) {
+			return nil
+		}
+	}
+	return nil
+}
+
+//key returns the ID or RefName as stored in the store
+func (s *Scoped) key(id string) string {
+	return s.tenant + TenantSeparator + id
+}
+
+//scope returns a copy of group with its ID and RefName prefixed
+func (s *Scoped) scope(group roller.Group) roller.Group {
+	group.ID = s.key(group.ID)
+	if group.RefName != "" {
+		group.RefName = s.key(group.RefName)
+	}
+	return group
+}
+
+//unscope returns a copy of group with the prefix of ID and RefName removed
+func (s *Scoped) unscope(group roller.Group) roller.Group {
+	prefix := s.key("")
+	group.ID = strings.TrimPrefix(group.ID, prefix)
+	group.RefName = strings.TrimPrefix(group.RefName, prefix)
+	return group
+}
+
+var _ RawListStorer = (*ScopedRawLists)(nil)
+
+//ScopedRawLists is a view of a RawListStorer, such as MemoryRawLists, that only sees the RawList of a single tenant
+//subjects are stored prefixed the same way as Scoped, and every RawList has RawList.Tenant set to the tenant
+type ScopedRawLists struct {
+	lists  RawListStorer
+	tenant string
+}
+
+//NewScopedRawLists creates the view of tenant over lists
+//returns InvalidTenantError if tenant is empty or contains TenantSeparator
+func NewScopedRawLists(lists RawListStorer, tenant string) (*ScopedRawLists, error) {
+	if err := checkTenant(tenant); err != nil {
+		return nil, err
+	}
+	return &ScopedRawLists{lists: lists, tenant: tenant}, nil
+}
+
+//Tenant returns the tenant of the view
+func (s *ScopedRawLists) Tenant() string {
+	return s.tenant
+}
+
+func (s *ScopedRawLists) RawList(subject string) (roller.RawList, error) {
+	l, err := s.lists.RawList(s.key(subject))
+	if err != nil {
+		var nf RawListNotFoundError
+		if errors.As(err, &nf) {
+			return roller.RawList{}, NewRawListNotFoundError(subject)
+		}
+		return roller.RawList{}, err
+	}
+	l.Tenant = s.tenant
+	return l, nil
+}
+
+//SetRawList stores the list under the tenant, RawList.Tenant is overwritten with the tenant
+func (s *ScopedRawLists) SetRawList(subject string, list roller.RawList) error {
+	list.Tenant = s.tenant
+	return s.lists.SetRawList(s.key(subject), list)
+}
+
+func (s *ScopedRawLists) RemoveRawList(subject string) error {
+	err := s.lists.RemoveRawList(s.key(subject))
+	var nf RawListNotFoundError
+	if errors.As(err, &nf) {
+		return NewRawListNotFoundError(subject)
+	}
+	return err
+}
+
+//WalkRawList walks the RawList of the tenant in the order of the underlying RawListStorer
+func (s *ScopedRawLists) WalkRawList(f func(subject string, list roller.RawList, last bool) (halt bool)) error {
+	type entry struct {
+		subject string
+		list    roller.RawList
+	}
+	var es []entry
+	prefix := s.key("")
+	if err := s.lists.WalkRawList(func(subject string, list roller.RawList, last bool) bool {
+		if strings.HasPrefix(subject, prefix) {
+			list.Tenant = s.tenant
+			es = append(es, entry{subject: strings.TrimPrefix(subject, prefix), list: list})
+		}
+		return false
+	}); err != nil {
+		return err
+	}
+	for i, e := range es {
+		if f(e.subject, e.list, len(es)-1 == i) {
+			return nil
+		}
+	}
+	return nil
+}
+
+func (s *ScopedRawLists) key(subject string) string {
+	return s.tenant + TenantSeparator + subject
+}
+
+//checkTenant returns InvalidTenantError if tenant can't be used as a prefix
+func checkTenant(tenant string) error {
+	if tenant == "" || strings.Contains(tenant, TenantSeparator) {
+		return NewInvalidTenantError(tenant)
+	}
+	return nil
+}
@@ -0,0 +1,169 @@
+package provider
+
+import (
+	"bytes"
+	"github.com/Thunder33345/roller"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestScoped(t *testing.T) {
+	r := require.New(t)
+	j, err := NewJSON(&bytes.Buffer{})
+	r.NoError(err)
+	r.NoError(j.AddGroup(roller.Group{ID: "member", Name: "Global Member"}))
+
+	acme, err := NewScoped(j, "acme", j)
+	r.NoError(err)
+	globex, err := NewScoped(j, "globex", nil)
+	r.NoError(err)
+
+	r.NoError(acme.AddGroup(roller.Group{ID: "100", RefName: "mod", Name: "Acme Mod"}))
+	r.NoError(globex.AddGroup(roller.Group{ID: "100", RefName: "mod", Name: "Globex Mod"}))
+
+	g, err := acme.Group("100")
+	r.NoError(err)
+	r.Equal(roller.Group{ID: "100", RefName: "mod", Name: "Acme Mod", Revision: 1}, g)
+	g, err = globex.Group("100")
+	r.NoError(err)
+	r.Equal("Globex Mod", g.Name)
+
+	stored, err := j.Group("acme/100")
+	r.NoError(err)
+	r.Equal("acme/mod", stored.RefName)
+
+	g, err = acme.Group("member")
+	r.NoError(err)
+	r.Equal("Global Member", g.Name)
+	_, err = globex.Group("member")
+	r.IsType(GroupNotFoundError{}, err)
+	_, err = acme.Group("globex/100")
+	r.Equal(NewGroupNotFoundError("globex/100"), err)
+
+	g, err = acme.GroupByRefName("mod")
+	r.NoError(err)
+	r.Equal("Acme Mod", g.Name)
+	_, err = acme.GroupByRefName("member")
+	r.Equal(NewRefNameNotFoundError("member"), err)
+
+	var ids []string
+	r.NoError(acme.WalkGroup(func(group roller.Group, last bool) bool {
+		ids = append(ids, group.ID)
+		r.True(last)
+		return false
+	}))
+	r.Equal([]string{"100"}, ids)
+
+	r.Equal(NewGroupNotFoundError("member"), acme.RemoveGroup("member"))
+	r.NoError(acme.RemoveGroup("100"))
+	_, err = acme.Group("100")
+	r.IsType(GroupNotFoundError{}, err)
+	_, err = globex.Group("100")
+	r.NoError(err)
+
+	tenants := NewTenants(j, j)
+	l, err := roller.BasicProcessor{Provider: tenants}.Process(roller.RawList{Groups: []string{"globex/100"}})
+	r.ErrorAs(err, &GroupNotFoundError{}, "a RawList without a tenant should not reach tenant scoped groups")
+	r.Equal(roller.List{}, l)
+	_, err = tenants.Group("member")
+	r.NoError(err)
+	r.Equal([]string{"member"}, txIDs(r, tenants))
+
+	_, err = NewScoped(j, "", nil)
+	r.Equal(NewInvalidTenantError(""), err)
+	_, err = NewScoped(j, "a/b", nil)
+	r.Equal(NewInvalidTenantError("a/b"), err)
+}
+
+func TestScoped_Bolt(t *testing.T) {
+	r := require.New(t)
+	b := newTestBolt(r, t.TempDir())
+	defer b.Close()
+	tenants := NewTenants(b, nil)
+
+	acme, err := tenants.Scoped("acme")
+	r.NoError(err)
+	globex, err := tenants.Scoped("globex")
+	r.NoError(err)
+	r.NoError(acme.AddGroup(roller.Group{ID: "100", RefName: "mod"}))
+	r.NoError(globex.AddGroup(roller.Group{ID: "200", RefName: "mod"}))
+
+	g, err := acme.GroupByRefName("mod")
+	r.NoError(err)
+	r.Equal("100", g.ID)
+	g, err = globex.GroupByRefName("mod")
+	r.NoError(err)
+	r.Equal("200", g.ID)
+
+	r.Error(acme.AddGroup(roller.Group{ID: "101", RefName: "mod"}))
+}
+
+func TestTenants(t *testing.T) {
+	r := require.New(t)
+	global, err := NewJSON(&bytes.Buffer{})
+	r.NoError(err)
+	r.NoError(global.AddGroup(roller.Group{ID: "member", Weight: 1, Permission: roller.Entry{Grant: []string{"chat"}}}))
+	r.NoError(global.AddGroup(roller.Group{ID: "mod", Weight: 2, Permission: roller.Entry{Grant: []string{"kick"}}}))
+
+	store, err := NewJSON(&bytes.Buffer{})
+	r.NoError(err)
+	tenants := NewTenants(store, global)
+	acme, err := tenants.Scoped("acme")
+	r.NoError(err)
+	r.NoError(acme.AddGroup(roller.Group{ID: "mod", Weight: 2, Permission: roller.Entry{Grant: []string{"ban"}}}))
+
+	p := roller.BasicProcessor{Provider: tenants}
+	l, err := p.Process(roller.RawList{Tenant: "acme", Groups: []string{"member", "mod"}})
+	r.NoError(err)
+	r.Equal([]string{"chat", "ban"}, l.Permission)
+	l, err = p.Process(roller.RawList{Tenant: "globex", Groups: []string{"member", "mod"}})
+	r.NoError(err)
+	r.Equal([]string{"chat", "kick"}, l.Permission)
+	l, err = p.Process(roller.RawList{Groups: []string{"mod"}})
+	r.NoError(err)
+	r.Equal([]string{"kick"}, l.Permission)
+
+	_, err = p.Process(roller.RawList{Tenant: "a/b", Groups: []string{"mod"}})
+	r.ErrorAs(err, &InvalidTenantError{})
+
+	r.NoError(global.AddBundle(roller.Bundle{Name: "social", Grant: []string{"chat.emote"}}))
+	r.NoError(store.AddBundle(roller.Bundle{Name: "staff", Grant: []string{"chat.mute"}}))
+	r.NoError(acme.AddGroup(roller.Group{ID: "helper", Permission: roller.Entry{Include: []string{"social", "staff"}}}))
+	l, err = p.Process(roller.RawList{Tenant: "acme", Groups: []string{"helper"}})
+	r.NoError(err)
+	r.Equal([]string{"chat.emote", "chat.mute"}, l.Permission, "bundles should be forwarded to global, then store")
+	_, err = tenants.Bundle("missing")
+	r.Equal(NewBundleNotFoundError("missing"), err)
+}
+
+func TestScopedRawLists(t *testing.T) {
+	r := require.New(t)
+	lists := &MemoryRawLists{}
+	acme, err := NewScopedRawLists(lists, "acme")
+	r.NoError(err)
+	globex, err := NewScopedRawLists(lists, "globex")
+	r.NoError(err)
+
+	r.NoError(acme.SetRawList("alice", roller.RawList{Groups: []string{"mod"}}))
+	r.NoError(globex.SetRawList("alice", roller.RawList{Groups: []string{"member"}}))
+
+	l, err := acme.RawList("alice")
+	r.NoError(err)
+	r.Equal(roller.RawList{Groups: []string{"mod"}, Tenant: "acme"}, l)
+	l, err = lists.RawList("globex/alice")
+	r.NoError(err)
+	r.Equal("globex", l.Tenant)
+
+	var subjects []string
+	r.NoError(globex.WalkRawList(func(subject string, list roller.RawList, last bool) bool {
+		subjects = append(subjects, subject)
+		r.Equal("globex", list.Tenant)
+		return false
+	}))
+	r.Equal([]string{"alice"}, subjects)
+
+	r.NoError(acme.RemoveRawList("alice"))
+	r.Equal(NewRawListNotFoundError("alice"), acme.RemoveRawList("alice"))
+	_, err = globex.RawList("alice")
+	r.NoError(err)
+}
@@ -13,6 +13,9 @@ import (
 
 var sliceArgs = genBenchArg(64, 5, []float64{-2, 2, -1.5, 1.5}, 4, 15)
 
+//largeSliceArgs are for comparing set based implementations against nested loops at 1k+ nodes
+var largeSliceArgs = genBenchArg(1024, 4, []float64{-2, 2, 8}, 2, 15)
+
 func removeNodesAppend(stack []string, needle []string) []string {
 	check := func(v string) bool {
 		for _, r := range needle {
@@ -142,10 +145,51 @@ func BenchmarkExternal_removeNodesDirtyReplace(b *testing.B) {
 	}
 }
 
+func removeNodesSet(stack []string, needle []string) []string {
+	set := make(map[string]struct{}, len(needle))
+	for _, n := range needle {
+		set[n] = struct{}{}
+	}
+	ret := make([]string, 0, len(stack))
+	for _, v := range stack {
+		if _, ok := set[v]; !ok {
+			ret = append(ret, v)
+		}
+	}
+	return ret
+}
+
+func BenchmarkExternal_removeNodesSet(b *testing.B) {
+	data := genBenchData(sliceArgs)
+	b.ResetTimer()
+	for _, c := range data {
+		b.Run(c.args.name, func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				removeNodesSet(c.haystack, c.needles)
+			}
+		})
+	}
+}
+
+func BenchmarkExternal_removeNodesLarge(b *testing.B) {
+	data := genBenchData(largeSliceArgs)
+	b.ResetTimer()
+	for _, f := range []removeNodes{removeNodesMakeCap, removeNodesSet} {
+		fn := strings.Split(getFunctionName(f), ".")[2]
+		for _, c := range data {
+			b.Run(fn+c.args.name, func(b *testing.B) {
+				for i := 0; i < b.N; i++ {
+					f(c.haystack, c.needles)
+				}
+			})
+		}
+	}
+}
+
 type removeNodes func(stack []string, needle []string) []string
 
 func getRemoveNodes() []removeNodes {
-	return []removeNodes{removeNodesAppend, removeNodesMakeCap, removeNodesDirtySwap}
+	return []removeNodes{removeNodesAppend, removeNodesMakeCap, removeNodesDirtySwap, removeNodesSet}
 }
 
 func TestExternal_removeNodes(t *testing.T) {